@@ -11,7 +11,8 @@ func main() {
 	// Simple client to talk to default-http example
 	uri := "http://127.0.0.1:8000"
 
-	client, err := socketio.NewClient(uri, nil)
+	// the go-socket.io server speaks engine.io v3.
+	client, err := socketio.NewClient(uri, &socketio.ClientOptions{EIO: 3})
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,177 @@
+package socketio
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Errors of the acks awaited with EmitWithAck.
+var (
+	ErrAckTimeout      = errors.New("ack timeout")
+	ErrAckDisconnected = errors.New("disconnected before ack")
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// ackWaiter is the ack of an emit of EmitWithAck, which is called once, with
+// the args of the ack of the peer, or with the error of its timeout or of the
+// lost connection.
+type ackWaiter struct {
+	ack *funcHandler
+
+	mu     sync.Mutex
+	done   bool
+	timer  *time.Timer
+	cancel func()
+}
+
+var interfacesType = reflect.TypeOf([]interface{}{})
+
+// newAckWaiter returns the waiter of ack, a func like func(error, ...), which
+// fails with ErrAckTimeout once timeout elapses, unless timeout is 0. A
+// variadic ack like func(error, ...interface{}) gets all the args.
+func newAckWaiter(ack interface{}, timeout time.Duration) *ackWaiter {
+	fv := reflect.ValueOf(ack)
+
+	if fv.Kind() != reflect.Func {
+		panic("ack callback must be a func.")
+	}
+
+	ft := fv.Type()
+	if ft.NumIn() < 1 || ft.In(0) != errorType {
+		panic("ack callback should be like func(error, ...)")
+	}
+	if ft.IsVariadic() && (ft.NumIn() < 2 || ft.In(ft.NumIn()-1) != interfacesType) {
+		panic("variadic ack callback should be like func(error, ...interface{})")
+	}
+
+	w := &ackWaiter{
+		ack: &funcHandler{f: fv},
+	}
+
+	if timeout > 0 {
+		w.timer = time.AfterFunc(timeout, func() {
+			w.fail(ErrAckTimeout)
+		})
+	}
+
+	return w
+}
+
+// handler gives the handler of the ack packet, which calls the waiter with a
+// nil error and the args of the ack.
+func (w *ackWaiter) handler() *funcHandler {
+	ft := w.ack.f.Type()
+
+	in := make([]reflect.Type, ft.NumIn()-1)
+	for i := range in {
+		in[i] = ft.In(i + 1)
+	}
+
+	f := reflect.MakeFunc(reflect.FuncOf(in, nil, ft.IsVariadic()), func(args []reflect.Value) []reflect.Value {
+		if !w.finish() {
+			return nil
+		}
+
+		args = append([]reflect.Value{reflect.Zero(errorType)}, args...)
+		if ft.IsVariadic() {
+			w.ack.f.CallSlice(args)
+		} else {
+			w.ack.f.Call(args)
+		}
+		return nil
+	})
+
+	// the variadic args are the rest of the decoded args.
+	argTypes := in
+	if ft.IsVariadic() {
+		argTypes = in[:len(in)-1]
+	}
+	if len(argTypes) == 0 {
+		argTypes = nil
+	}
+
+	return &funcHandler{
+		argTypes: argTypes,
+		f:        f,
+		fail:     w.fail,
+		rest:     ft.IsVariadic(),
+	}
+}
+
+// setCancel sets cancel to remove the waiter from the pending acks once it's
+// done, and calls it if it's done already.
+func (w *ackWaiter) setCancel(cancel func()) {
+	w.mu.Lock()
+	done := w.done
+	w.cancel = cancel
+	w.mu.Unlock()
+
+	if done {
+		cancel()
+	}
+}
+
+// fail calls the waiter with err, and the zero values of the args.
+func (w *ackWaiter) fail(err error) {
+	if !w.finish() {
+		return
+	}
+
+	ft := w.ack.f.Type()
+
+	// the variadic args are left empty.
+	n := ft.NumIn()
+	if ft.IsVariadic() {
+		n--
+	}
+
+	args := make([]reflect.Value, n)
+	args[0] = reflect.ValueOf(&err).Elem()
+	for i := 1; i < len(args); i++ {
+		args[i] = reflect.Zero(ft.In(i))
+	}
+
+	// the panics of the callback are recovered, as there is no handler to
+	// report them to.
+	_, _ = w.ack.Call(args)
+}
+
+// finish marks the waiter done, and tells if it wasn't already.
+func (w *ackWaiter) finish() bool {
+	w.mu.Lock()
+	if w.done {
+		w.mu.Unlock()
+		return false
+	}
+
+	w.done = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	cancel := w.cancel
+	w.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	return true
+}
+
+// MarshalJSON keeps the emits awaiting an ack out of the persisted send
+// buffer, like the emits with an ack func.
+func (w *ackWaiter) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("ack can't be marshaled")
+}
+
+// failAck fails the ack of EmitWithAck in the args of an emit with err, if any.
+func failAck(args []interface{}, err error) {
+	if l := len(args); l > 0 {
+		if w, ok := args[l-1].(*ackWaiter); ok {
+			w.fail(err)
+		}
+	}
+}
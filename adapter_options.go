@@ -1,6 +1,13 @@
 package socketio
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+
+	"github.com/googollee/go-socket.io/metrics"
+)
+
+const defaultRequestsTimeout = 5 * time.Second
 
 // RedisAdapterOptions is configuration to create new adapter
 type RedisAdapterOptions struct {
@@ -14,6 +21,12 @@ type RedisAdapterOptions struct {
 	Password string
 	// DB : specifies the database to select when dialing a connection.
 	DB int
+	// RequestsTimeout is how long to wait for the other nodes to answer
+	// requests like Len or FetchSockets. It's 5 seconds by default.
+	RequestsTimeout time.Duration
+
+	metrics metrics.Metrics
+	tracer  *tracer
 }
 
 func (ro *RedisAdapterOptions) getAddr() string {
@@ -23,6 +36,13 @@ func (ro *RedisAdapterOptions) getAddr() string {
 	return ro.Addr
 }
 
+func (ro *RedisAdapterOptions) getRequestsTimeout() time.Duration {
+	if ro == nil || ro.RequestsTimeout <= 0 {
+		return defaultRequestsTimeout
+	}
+	return ro.RequestsTimeout
+}
+
 func defaultOptions() *RedisAdapterOptions {
 	return &RedisAdapterOptions{
 		Addr:    "127.0.0.1:6379",
@@ -58,6 +78,10 @@ func getOptions(opts *RedisAdapterOptions) *RedisAdapterOptions {
 		if len(opts.Password) > 0 {
 			options.Password = opts.Password
 		}
+
+		if opts.RequestsTimeout > 0 {
+			options.RequestsTimeout = opts.RequestsTimeout
+		}
 	}
 
 	return options
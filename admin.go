@@ -0,0 +1,390 @@
+package socketio
+
+import (
+	"crypto/subtle"
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+var errAdminUnauthorized = errors.New("admin: invalid credentials")
+
+// admin features, as named by the admin UI.
+const (
+	adminFeatureJoin        = "JOIN"
+	adminFeatureLeave       = "LEAVE"
+	adminFeatureDisconnect  = "DISCONNECT"
+	adminFeatureMJoin       = "MJOIN"
+	adminFeatureMLeave      = "MLEAVE"
+	adminFeatureMDisconnect = "MDISCONNECT"
+	adminFeatureAllEvents   = "ALL_EVENTS"
+)
+
+type adminConfig struct {
+	SupportedFeatures []string `json:"supportedFeatures"`
+}
+
+type adminNamespaceStats struct {
+	Name         string `json:"name"`
+	SocketsCount int    `json:"socketsCount"`
+}
+
+type adminServerStats struct {
+	ServerID            string                `json:"serverId"`
+	Hostname            string                `json:"hostname"`
+	PID                 int                   `json:"pid"`
+	Uptime              float64               `json:"uptime"`
+	ClientsCount        int                   `json:"clientsCount"`
+	PollingClientsCount int                   `json:"pollingClientsCount"`
+	Namespaces          []adminNamespaceStats `json:"namespaces"`
+}
+
+type adminSocket struct {
+	ID        string                 `json:"id"`
+	ClientID  string                 `json:"clientId"`
+	Transport string                 `json:"transport"`
+	Namespace string                 `json:"nsp"`
+	Data      map[string]interface{} `json:"data"`
+	Handshake Handshake              `json:"handshake"`
+	Rooms     []string               `json:"rooms"`
+}
+
+// admin serves the Socket.IO Admin UI protocol. The hooks are called by the
+// connections of the server, and are no-ops on a nil admin.
+type admin struct {
+	server          *Server
+	namespace       string
+	username        string
+	password        string
+	unauthenticated bool
+	readOnly        bool
+	serverID        string
+	hostname        string
+	started         time.Time
+	interval        time.Duration
+
+	// sockets is a map of namespace to the connected sockets of this server.
+	sockets map[string]map[string]*namespaceConn
+	mu      sync.RWMutex
+
+	quit      chan struct{}
+	closeOnce sync.Once
+}
+
+func newAdmin(s *Server, opts *AdminOptions) *admin {
+	hostname, _ := os.Hostname()
+
+	a := &admin{
+		server:    s,
+		namespace: opts.getNamespace(),
+		serverID:  opts.getServerID(),
+		hostname:  hostname,
+		started:   time.Now(),
+		interval:  opts.getStatsInterval(),
+		sockets:   make(map[string]map[string]*namespaceConn),
+		quit:      make(chan struct{}),
+	}
+
+	if opts != nil {
+		a.username = opts.Username
+		a.password = opts.Password
+		a.unauthenticated = opts.AllowUnauthenticated
+		a.readOnly = opts.ReadOnly
+	}
+
+	return a
+}
+
+func (a *admin) close() {
+	if a == nil {
+		return
+	}
+
+	a.closeOnce.Do(func() {
+		close(a.quit)
+	})
+}
+
+func (a *admin) features() []string {
+	if a.readOnly {
+		return []string{adminFeatureAllEvents}
+	}
+
+	return []string{
+		adminFeatureJoin,
+		adminFeatureLeave,
+		adminFeatureDisconnect,
+		adminFeatureMJoin,
+		adminFeatureMLeave,
+		adminFeatureMDisconnect,
+		adminFeatureAllEvents,
+	}
+}
+
+func (a *admin) authorized(auth map[string]interface{}) bool {
+	if a.username == "" && a.password == "" {
+		return a.unauthenticated
+	}
+
+	username, _ := auth["username"].(string)
+	password, _ := auth["password"].(string)
+
+	usernameOK := subtle.ConstantTimeCompare([]byte(username), []byte(a.username)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(a.password)) == 1
+
+	return usernameOK && passwordOK
+}
+
+func (a *admin) onConnect(conn Conn) error {
+	if !a.authorized(conn.Handshake().Auth) {
+		return errAdminUnauthorized
+	}
+
+	return nil
+}
+
+// afterConnect sends the config and the sockets of all the servers to the
+// admin UI, once its namespace is connected.
+func (a *admin) afterConnect(conn Conn) {
+	conn.Emit("config", adminConfig{SupportedFeatures: a.features()})
+	conn.Emit("all_sockets", a.allSockets())
+}
+
+func (a *admin) serveStats() {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-ticker.C:
+			a.server.BroadcastToNamespace(a.namespace, "server_stats", a.stats())
+		}
+	}
+}
+
+func (a *admin) stats() adminServerStats {
+	stats := adminServerStats{
+		ServerID:     a.serverID,
+		Hostname:     a.hostname,
+		PID:          os.Getpid(),
+		Uptime:       time.Since(a.started).Seconds(),
+		ClientsCount: a.server.Count(),
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	polling := make(map[string]struct{})
+	for _, sockets := range a.sockets {
+		for id, socket := range sockets {
+			if socket.Transport() == "polling" {
+				polling[id] = struct{}{}
+			}
+		}
+	}
+	stats.PollingClientsCount = len(polling)
+
+	a.server.handlers.Range(func(nsp string, _ *namespaceHandler) {
+		stats.Namespaces = append(stats.Namespaces, adminNamespaceStats{
+			Name:         namespaceName(nsp),
+			SocketsCount: len(a.sockets[namespaceName(nsp)]),
+		})
+	})
+	sort.Slice(stats.Namespaces, func(i, j int) bool {
+		return stats.Namespaces[i].Name < stats.Namespaces[j].Name
+	})
+
+	return stats
+}
+
+// allSockets gives the sockets of all the namespaces, over all the servers
+// sharing the adapter.
+func (a *admin) allSockets() []adminSocket {
+	sockets := make([]adminSocket, 0)
+
+	a.server.handlers.Range(func(_ string, handler *namespaceHandler) {
+		for _, socket := range handler.broadcast.FetchSockets("") {
+			sockets = append(sockets, adminSocket{
+				ID:        socket.ID,
+				ClientID:  socket.ID,
+				Transport: socket.Transport,
+				Namespace: namespaceName(socket.Namespace),
+				Data:      socket.Data,
+				Handshake: socket.Handshake,
+				Rooms:     socket.Rooms,
+			})
+		}
+	})
+
+	return sockets
+}
+
+func (a *admin) serialize(nc *namespaceConn) adminSocket {
+	return adminSocket{
+		ID:        nc.ID(),
+		ClientID:  nc.ID(),
+		Transport: nc.Transport(),
+		Namespace: namespaceName(nc.Namespace()),
+		Data:      nc.Data().Snapshot(),
+		Handshake: nc.Handshake(),
+		Rooms:     nc.Rooms(),
+	}
+}
+
+// matching gives the connections of this server in the namespace which are
+// in the room filter. Every connection is in the room of its ID, so filter
+// can be a connection ID as well.
+func (a *admin) matching(nsp, filter string) []Conn {
+	handler := a.server.getNamespace(nsp)
+	if handler == nil {
+		return nil
+	}
+
+	var conns []Conn
+	handler.broadcast.ForEach(filter, func(conn Conn) {
+		conns = append(conns, conn)
+	})
+
+	return conns
+}
+
+func (a *admin) join(_ Conn, nsp, room, filter string) {
+	for _, conn := range a.matching(nsp, filter) {
+		conn.Join(room)
+	}
+}
+
+func (a *admin) leave(_ Conn, nsp, room, filter string) {
+	for _, conn := range a.matching(nsp, filter) {
+		conn.Leave(room)
+	}
+}
+
+// disconnect closes the matching connections. Connections are always closed,
+// as a single namespace can't be disconnected by the server.
+func (a *admin) disconnect(_ Conn, nsp string, _ bool, filter string) {
+	for _, conn := range a.matching(nsp, filter) {
+		_ = conn.Close()
+	}
+}
+
+func (a *admin) tracked(nc *namespaceConn) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	_, ok := a.sockets[namespaceName(nc.Namespace())][nc.ID()]
+	return ok
+}
+
+// watched reports whether an admin UI is connected to this server. The hooks
+// don't serialize nor broadcast anything while none is.
+func (a *admin) watched() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return len(a.sockets[a.namespace]) > 0
+}
+
+// observed reports whether the events of nc are sent to the admin UI.
+func (a *admin) observed(nc *namespaceConn) bool {
+	return a != nil && namespaceName(nc.Namespace()) != a.namespace && a.watched() && a.tracked(nc)
+}
+
+func (a *admin) send(event string, args ...interface{}) {
+	a.server.BroadcastToNamespace(a.namespace, event, args...)
+}
+
+func (a *admin) socketConnected(nc *namespaceConn) {
+	if a == nil {
+		return
+	}
+
+	nsp := namespaceName(nc.Namespace())
+
+	a.mu.Lock()
+	if _, ok := a.sockets[nsp]; !ok {
+		a.sockets[nsp] = make(map[string]*namespaceConn)
+	}
+	a.sockets[nsp][nc.ID()] = nc
+	a.mu.Unlock()
+
+	if nsp != a.namespace && a.watched() {
+		a.send("socket_connected", a.serialize(nc), time.Now())
+	}
+}
+
+func (a *admin) socketDisconnected(nc *namespaceConn, reason string) {
+	observed := a.observed(nc)
+	a.untrack(nc)
+
+	if observed {
+		a.send("socket_disconnected", namespaceName(nc.Namespace()), nc.ID(), reason)
+	}
+}
+
+func (a *admin) untrack(nc *namespaceConn) {
+	if a == nil {
+		return
+	}
+
+	nsp := namespaceName(nc.Namespace())
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.sockets[nsp], nc.ID())
+	if len(a.sockets[nsp]) == 0 {
+		delete(a.sockets, nsp)
+	}
+}
+
+func (a *admin) roomJoined(nc *namespaceConn, room string) {
+	if a.observed(nc) {
+		a.send("room_joined", namespaceName(nc.Namespace()), room, nc.ID())
+	}
+}
+
+func (a *admin) roomLeft(nc *namespaceConn, room string) {
+	if a.observed(nc) {
+		a.send("room_left", namespaceName(nc.Namespace()), room, nc.ID())
+	}
+}
+
+// rooms gives the rooms of nc, if they are reported to the admin UI when nc
+// leaves all of them.
+func (a *admin) rooms(nc *namespaceConn) []string {
+	if !a.observed(nc) {
+		return nil
+	}
+
+	return nc.Rooms()
+}
+
+func (a *admin) eventReceived(nc *namespaceConn, event string, args []reflect.Value) {
+	if !a.observed(nc) {
+		return
+	}
+
+	values := make([]interface{}, 0, len(args)+1)
+	values = append(values, event)
+	for _, arg := range args {
+		values = append(values, arg.Interface())
+	}
+
+	a.send("event_received", namespaceName(nc.Namespace()), nc.ID(), values, time.Now())
+}
+
+func (a *admin) eventSent(nc *namespaceConn, event string, args []interface{}) {
+	if !a.observed(nc) {
+		return
+	}
+
+	values := append([]interface{}{event}, args...)
+
+	a.send("event_sent", namespaceName(nc.Namespace()), nc.ID(), values, time.Now())
+}
@@ -0,0 +1,48 @@
+package socketio
+
+import (
+	"os"
+	"time"
+)
+
+// AdminOptions is configuration of the Socket.IO Admin UI support.
+type AdminOptions struct {
+	// Namespace is the namespace the admin UI connects to, "/admin" by default.
+	Namespace string
+	// Username and Password are the basic-auth credentials the admin UI sends
+	// in its auth payload. The admin UI is refused if both are empty, unless
+	// AllowUnauthenticated is set.
+	Username string
+	Password string
+	// AllowUnauthenticated serves the admin UI without credentials, to anyone
+	// who can reach the server.
+	AllowUnauthenticated bool
+	// ReadOnly disables the join, leave and disconnect actions.
+	ReadOnly bool
+	// ServerID identifies the server in the admin UI, the hostname by default.
+	ServerID string
+	// StatsInterval is the interval to send server stats, 2 seconds by default.
+	StatsInterval time.Duration
+}
+
+func (o *AdminOptions) getNamespace() string {
+	if o != nil && o.Namespace != "" {
+		return o.Namespace
+	}
+	return "/admin"
+}
+
+func (o *AdminOptions) getServerID() string {
+	if o != nil && o.ServerID != "" {
+		return o.ServerID
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
+func (o *AdminOptions) getStatsInterval() time.Duration {
+	if o != nil && o.StatsInterval != 0 {
+		return o.StatsInterval
+	}
+	return 2 * time.Second
+}
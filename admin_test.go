@@ -0,0 +1,90 @@
+package socketio
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/metrics"
+	"github.com/googollee/go-socket.io/parser"
+)
+
+func TestAdminAuthorized(t *testing.T) {
+	should := assert.New(t)
+
+	a := newAdmin(nil, nil)
+	should.False(a.authorized(nil))
+	should.False(a.authorized(map[string]interface{}{"username": "", "password": ""}))
+	should.Equal("/admin", a.namespace)
+
+	a = newAdmin(nil, &AdminOptions{AllowUnauthenticated: true})
+	should.True(a.authorized(nil))
+
+	a = newAdmin(nil, &AdminOptions{Username: "admin", Password: "secret", ReadOnly: true})
+	should.True(a.authorized(map[string]interface{}{"username": "admin", "password": "secret"}))
+	should.False(a.authorized(map[string]interface{}{"username": "admin", "password": "wrong"}))
+	should.False(a.authorized(nil))
+	should.Equal([]string{adminFeatureAllEvents}, a.features())
+}
+
+func TestAdminHooks(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	s := NewServer(nil)
+	defer s.Close()
+
+	s.OnConnect("/chat", func(Conn) error { return nil })
+	s.Admin(nil)
+
+	ui := &fakeConn{id: "ui"}
+	s.getNamespace("/admin").broadcast.Join("ui", ui)
+
+	c := newConn(&fakeEngineConn{id: "sid"}, s.handlers, parser.JSON, metrics.Nop{}, newTracer(), logger.Log)
+	c.admin = s.admin
+	nc := newNamespaceConn(c, "/chat", s.getNamespace("/chat").broadcast)
+	c.namespaces.Set("/chat", nc)
+	nc.Join(c.ID())
+
+	// nothing is sent while no admin UI is connected to the server.
+	s.admin.socketConnected(nc)
+	s.admin.eventReceived(nc, "message", []reflect.Value{reflect.ValueOf("hello")})
+	s.admin.eventSent(nc, "message", []interface{}{"hello"})
+	should.Empty(ui.emitted)
+
+	uiConn := newConn(&fakeEngineConn{id: "ui"}, s.handlers, parser.JSON, metrics.Nop{}, newTracer(), logger.Log)
+	s.admin.socketConnected(newNamespaceConn(uiConn, "/admin", s.getNamespace("/admin").broadcast))
+	s.admin.socketConnected(nc)
+
+	must.Len(ui.emitted, 1)
+	should.Equal("socket_connected", ui.emitted[0].event)
+	socket := ui.emitted[0].args[0].(adminSocket)
+	should.Equal("sid", socket.ID)
+	should.Equal("/chat", socket.Namespace)
+	should.Equal("polling", socket.Transport)
+	should.Equal([]string{"sid"}, socket.Rooms)
+
+	s.admin.join(nil, "/chat", "room", "sid")
+
+	must.Len(ui.emitted, 2)
+	should.Equal("room_joined", ui.emitted[1].event)
+	should.Equal([]interface{}{"/chat", "room", "sid"}, ui.emitted[1].args)
+	should.ElementsMatch([]string{"sid", "room"}, nc.Rooms())
+
+	stats := s.admin.stats()
+	should.Equal(2, stats.PollingClientsCount)
+	should.Contains(stats.Namespaces, adminNamespaceStats{Name: "/chat", SocketsCount: 1})
+	should.Contains(stats.Namespaces, adminNamespaceStats{Name: "/admin", SocketsCount: 1})
+
+	s.admin.socketDisconnected(nc, clientDisconnectMsg)
+
+	must.Len(ui.emitted, 3)
+	should.Equal([]interface{}{"/chat", "sid", clientDisconnectMsg}, ui.emitted[2].args)
+
+	nc.Leave("room")
+
+	should.Len(ui.emitted, 3)
+}
@@ -1,36 +1,61 @@
 package socketio
 
-import "sync"
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
 
 // EachFunc typed for each callback function
 type EachFunc func(Conn)
 
+// RemoteSocket is a snapshot of a connection given by FetchSockets. With the
+// redis adapter the connection may be served by another node.
+type RemoteSocket struct {
+	ID        string                 `json:"id"`
+	Namespace string                 `json:"namespace"`
+	Rooms     []string               `json:"rooms"`
+	Data      map[string]interface{} `json:"data"`
+	Handshake Handshake              `json:"handshake"`
+	Transport string                 `json:"transport"`
+}
+
 // Broadcast is the adaptor to handle broadcasts & rooms for socket.io server API
 type Broadcast interface {
-	Join(room string, connection Conn)            // Join causes the connection to join a room
-	Leave(room string, connection Conn)           // Leave causes the connection to leave a room
-	LeaveAll(connection Conn)                     // LeaveAll causes given connection to leave all rooms
-	Clear(room string)                            // Clear causes removal of all connections from the room
-	Send(room, event string, args ...interface{}) // Send will send an event with args to the room
-	SendAll(event string, args ...interface{})    // SendAll will send an event with args to all the rooms
-	ForEach(room string, f EachFunc)              // ForEach sends data by DataFunc, if room does not exits sends nothing
-	Len(room string) int                          // Len gives number of connections in the room
-	Rooms(connection Conn) []string               // Gives list of all the rooms if no connection given, else list of all the rooms the connection joined
-	AllRooms() []string                           // Gives list of all the rooms the connection joined
+	Join(room string, connection Conn)                                      // Join causes the connection to join a room
+	JoinPresence(room string, connection Conn, meta map[string]interface{}) // JoinPresence causes the connection to join a room and tracks its presence with meta
+	Leave(room string, connection Conn)                                     // Leave causes the connection to leave a room
+	LeaveAll(connection Conn)                                               // LeaveAll causes given connection to leave all rooms
+	Clear(room string)                                                      // Clear causes removal of all connections from the room
+	Send(ctx context.Context, room, event string, args ...interface{})      // Send will send an event with args to the room, traced as a child of ctx
+	SendAll(ctx context.Context, event string, args ...interface{})         // SendAll will send an event with args to all the rooms, traced as a child of ctx
+	ForEach(room string, f EachFunc)                                        // ForEach sends data by DataFunc, if room does not exits sends nothing
+	Len(room string) int                                                    // Len gives number of connections in the room
+	Rooms(connection Conn) []string                                         // Gives list of all the rooms if no connection given, else list of all the rooms the connection joined
+	AllRooms() []string                                                     // Gives list of all the rooms the connection joined
+	Presence(room string) []PresenceMember                                  // Presence gives list of all the members tracked in the room with their metadata
+	FetchSockets(room string) []RemoteSocket                                // FetchSockets gives snapshots of the connections in the room, or of all the connections if room is empty
 }
 
 // broadcast gives Join, Leave & BroadcastTO server API support to socket.io along with room management
 // map of rooms where each room contains a map of connection id to connections in that room
 type broadcast struct {
-	rooms map[string]map[string]Conn
+	nsp      string
+	tracer   *tracer
+	rooms    map[string]map[string]Conn
+	presence presenceRooms
 
 	lock sync.RWMutex
 }
 
 // newBroadcast creates a new broadcast adapter
-func newBroadcast() *broadcast {
+func newBroadcast(nsp string, t *tracer) *broadcast {
 	return &broadcast{
-		rooms: make(map[string]map[string]Conn),
+		nsp:      nsp,
+		tracer:   t,
+		rooms:    make(map[string]map[string]Conn),
+		presence: make(presenceRooms),
 	}
 }
 
@@ -46,10 +71,26 @@ func (bc *broadcast) Join(room string, connection Conn) {
 	bc.rooms[room][connection.ID()] = connection
 }
 
+// JoinPresence joins the given connection to the broadcast room and tracks its
+// presence with meta. Members of the room receive the presence diff.
+func (bc *broadcast) JoinPresence(room string, connection Conn, meta map[string]interface{}) {
+	bc.lock.Lock()
+
+	if _, ok := bc.rooms[room]; !ok {
+		bc.rooms[room] = make(map[string]Conn)
+	}
+
+	bc.rooms[room][connection.ID()] = connection
+	diff := bc.presence.set(room, PresenceMember{ID: connection.ID(), Meta: meta})
+
+	bc.lock.Unlock()
+
+	bc.sendPresenceDiffs(diff)
+}
+
 // Leave leaves the given connection from given room (if exist)
 func (bc *broadcast) Leave(room string, connection Conn) {
 	bc.lock.Lock()
-	defer bc.lock.Unlock()
 
 	if connections, ok := bc.rooms[room]; ok {
 		delete(connections, connection.ID())
@@ -58,12 +99,16 @@ func (bc *broadcast) Leave(room string, connection Conn) {
 			delete(bc.rooms, room)
 		}
 	}
+	diff := bc.presence.remove(room, connection.ID())
+
+	bc.lock.Unlock()
+
+	bc.sendPresenceDiffs(diff)
 }
 
 // LeaveAll leaves the given connection from all rooms
 func (bc *broadcast) LeaveAll(connection Conn) {
 	bc.lock.Lock()
-	defer bc.lock.Unlock()
 
 	for room, connections := range bc.rooms {
 		delete(connections, connection.ID())
@@ -72,6 +117,11 @@ func (bc *broadcast) LeaveAll(connection Conn) {
 			delete(bc.rooms, room)
 		}
 	}
+	diffs := bc.presence.removeAll(connection.ID())
+
+	bc.lock.Unlock()
+
+	bc.sendPresenceDiffs(diffs...)
 }
 
 // Clear clears the room
@@ -80,10 +130,15 @@ func (bc *broadcast) Clear(room string) {
 	defer bc.lock.Unlock()
 
 	delete(bc.rooms, room)
+	delete(bc.presence, room)
 }
 
 // Send sends given event & args to all the connections in the specified room
-func (bc *broadcast) Send(room, event string, args ...interface{}) {
+func (bc *broadcast) Send(ctx context.Context, room, event string, args ...interface{}) {
+	_, span := bc.tracer.start(ctx, broadcastSpanName, trace.SpanKindProducer,
+		namespaceAttr.String(namespaceName(bc.nsp)), roomAttr.String(room), eventAttr.String(event))
+	defer span.End()
+
 	bc.lock.RLock()
 	defer bc.lock.RUnlock()
 
@@ -93,7 +148,11 @@ func (bc *broadcast) Send(room, event string, args ...interface{}) {
 }
 
 // SendAll sends given event & args to all the connections to all the rooms
-func (bc *broadcast) SendAll(event string, args ...interface{}) {
+func (bc *broadcast) SendAll(ctx context.Context, event string, args ...interface{}) {
+	_, span := bc.tracer.start(ctx, broadcastSpanName, trace.SpanKindProducer,
+		namespaceAttr.String(namespaceName(bc.nsp)), eventAttr.String(event))
+	defer span.End()
+
 	bc.lock.RLock()
 	defer bc.lock.RUnlock()
 
@@ -154,6 +213,31 @@ func (bc *broadcast) AllRooms() []string {
 	return rooms
 }
 
+// Presence gives list of all the members tracked in the room
+func (bc *broadcast) Presence(room string) []PresenceMember {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+
+	return bc.presence.members(room)
+}
+
+// FetchSockets gives snapshots of the connections in the room, or of all the
+// connections if room is empty
+func (bc *broadcast) FetchSockets(room string) []RemoteSocket {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+
+	return fetchSockets(bc.rooms, room)
+}
+
+func (bc *broadcast) sendPresenceDiffs(diffs ...PresenceDiff) {
+	for _, diff := range diffs {
+		if !diff.empty() {
+			bc.Send(context.Background(), diff.Room, presenceDiffEvent, diff)
+		}
+	}
+}
+
 func (bc *broadcast) getRoomsByConn(connection Conn) []string {
 	var rooms []string
 
@@ -165,3 +249,42 @@ func (bc *broadcast) getRoomsByConn(connection Conn) []string {
 
 	return rooms
 }
+
+// fetchSockets gives snapshots of the connections in the room of rooms, or of
+// all the connections in rooms if room is empty.
+func fetchSockets(rooms map[string]map[string]Conn, room string) []RemoteSocket {
+	connections := make(map[string]Conn)
+	if room == "" {
+		for _, occupants := range rooms {
+			for id, connection := range occupants {
+				connections[id] = connection
+			}
+		}
+	} else {
+		connections = rooms[room]
+	}
+
+	sockets := make([]RemoteSocket, 0, len(connections))
+	for id, connection := range connections {
+		socket := RemoteSocket{
+			ID:        id,
+			Namespace: connection.Namespace(),
+			Data:      connection.Data().Snapshot(),
+			Handshake: connection.Handshake(),
+		}
+
+		if t, ok := connection.(interface{ Transport() string }); ok {
+			socket.Transport = t.Transport()
+		}
+
+		for name, occupants := range rooms {
+			if _, ok := occupants[id]; ok {
+				socket.Rooms = append(socket.Rooms, name)
+			}
+		}
+
+		sockets = append(sockets, socket)
+	}
+
+	return sockets
+}
@@ -0,0 +1,228 @@
+package socketio
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/engineio/transport/polling"
+	"github.com/googollee/go-socket.io/engineio/transport/websocket"
+	"github.com/googollee/go-socket.io/logger"
+)
+
+// ClientOptions is configuration of a Client or a Manager. The options apply
+// to both the polling and websocket transports.
+type ClientOptions struct {
+	// Transports are the names of the transports dialed like engineio.Dialer:
+	// "polling" then "websocket" by default, the polling connection being
+	// upgraded to websocket.
+	Transports []string
+	// CustomTransports are dialed by their name in Transports instead of the
+	// transports of the options, like the in-memory transport of tests.
+	CustomTransports []transport.Transport
+	// EIO is the engine.io protocol version, 4 by default for socket.io v3
+	// and v4 servers, or 3 for socket.io v2 servers, like go-socket.io
+	// servers.
+	EIO int
+
+	// Header is the extra headers of the requests to the server.
+	Header http.Header
+	// Query is the extra query params of the requests to the server.
+	Query url.Values
+	// Auth is the payload of the connect packets of the sockets whose SetAuth
+	// isn't called, which the server gets with Handshake.
+	Auth interface{}
+
+	// TLSClientConfig is the TLS configuration of https and wss connections.
+	TLSClientConfig *tls.Config
+	// Proxy returns the proxy of a request, http.ProxyFromEnvironment by
+	// default.
+	Proxy func(*http.Request) (*url.URL, error)
+	// Jar keeps the cookies of the server, like the ones of sticky sessions.
+	Jar http.CookieJar
+	// HTTPClient is the client of the polling requests. TLSClientConfig,
+	// Proxy, Jar and NetDial don't apply to polling if it's set.
+	HTTPClient *http.Client
+	// NetDial dials the TCP connections of the transports.
+	NetDial func(network, addr string) (net.Conn, error)
+
+	// ConnectTimeout is the timeout of dialing a TCP connection, 20 seconds by
+	// default.
+	ConnectTimeout time.Duration
+	// HandshakeTimeout is the timeout of the TLS handshake, and of the
+	// handshake of the transports, 20 seconds by default.
+	HandshakeTimeout time.Duration
+
+	// SendBuffer buffers the emits of the sockets which aren't connected, to
+	// send them once connected. The emits are dropped if it's nil.
+	SendBuffer *SendBufferOptions
+
+	// Logger logs the client. logger.Log is used by default.
+	Logger *slog.Logger
+}
+
+func (o *ClientOptions) getTransports() []string {
+	if o != nil && len(o.Transports) != 0 {
+		return o.Transports
+	}
+	return []string{"polling", "websocket"}
+}
+
+func (o *ClientOptions) getEIO() int {
+	if o != nil && o.EIO == 3 {
+		return 3
+	}
+	return 4
+}
+
+func (o *ClientOptions) getHeader() http.Header {
+	if o != nil && o.Header != nil {
+		return o.Header.Clone()
+	}
+	return http.Header{}
+}
+
+func (o *ClientOptions) getQuery() url.Values {
+	if o != nil {
+		return o.Query
+	}
+	return nil
+}
+
+func (o *ClientOptions) getAuth() interface{} {
+	if o != nil {
+		return o.Auth
+	}
+	return nil
+}
+
+func (o *ClientOptions) getTLSClientConfig() *tls.Config {
+	if o != nil {
+		return o.TLSClientConfig
+	}
+	return nil
+}
+
+func (o *ClientOptions) getProxy() func(*http.Request) (*url.URL, error) {
+	if o != nil && o.Proxy != nil {
+		return o.Proxy
+	}
+	return http.ProxyFromEnvironment
+}
+
+func (o *ClientOptions) getJar() http.CookieJar {
+	if o != nil {
+		return o.Jar
+	}
+	return nil
+}
+
+func (o *ClientOptions) getConnectTimeout() time.Duration {
+	if o != nil && o.ConnectTimeout > 0 {
+		return o.ConnectTimeout
+	}
+	return 20 * time.Second
+}
+
+func (o *ClientOptions) getHandshakeTimeout() time.Duration {
+	if o != nil && o.HandshakeTimeout > 0 {
+		return o.HandshakeTimeout
+	}
+	return 20 * time.Second
+}
+
+func (o *ClientOptions) getSendBuffer() *SendBufferOptions {
+	if o != nil {
+		return o.SendBuffer
+	}
+	return nil
+}
+
+func (o *ClientOptions) getLogger() *slog.Logger {
+	if o != nil && o.Logger != nil {
+		return o.Logger
+	}
+	return logger.Log
+}
+
+func (o *ClientOptions) getNetDial() func(network, addr string) (net.Conn, error) {
+	if o != nil && o.NetDial != nil {
+		return o.NetDial
+	}
+
+	dialer := &net.Dialer{Timeout: o.getConnectTimeout()}
+
+	return dialer.Dial
+}
+
+func (o *ClientOptions) getHTTPClient() *http.Client {
+	if o != nil && o.HTTPClient != nil {
+		return o.HTTPClient
+	}
+
+	netDial := o.getNetDial()
+
+	return &http.Client{
+		Jar: o.getJar(),
+		Transport: &http.Transport{
+			Proxy: o.getProxy(),
+			DialContext: func(_ context.Context, network, addr string) (net.Conn, error) {
+				return netDial(network, addr)
+			},
+			TLSClientConfig:     o.getTLSClientConfig(),
+			TLSHandshakeTimeout: o.getHandshakeTimeout(),
+		},
+	}
+}
+
+// transports gives the transports to dial, configured with the options.
+func (o *ClientOptions) transports() ([]transport.Transport, error) {
+	ret := make([]transport.Transport, 0, len(o.getTransports()))
+
+	for _, name := range o.getTransports() {
+		if t, ok := o.customTransport(name); ok {
+			ret = append(ret, t)
+			continue
+		}
+
+		switch name {
+		case "polling":
+			ret = append(ret, &polling.Transport{
+				Client:           o.getHTTPClient(),
+				HandshakeTimeout: o.getHandshakeTimeout(),
+			})
+		case "websocket":
+			ret = append(ret, &websocket.Transport{
+				TLSClientConfig:  o.getTLSClientConfig(),
+				HandshakeTimeout: o.getHandshakeTimeout(),
+				Proxy:            o.getProxy(),
+				NetDial:          o.getNetDial(),
+				Jar:              o.getJar(),
+			})
+		default:
+			return nil, fmt.Errorf("unknown transport %q", name)
+		}
+	}
+
+	return ret, nil
+}
+
+func (o *ClientOptions) customTransport(name string) (transport.Transport, bool) {
+	if o == nil {
+		return nil, false
+	}
+
+	for _, t := range o.CustomTransports {
+		if t.Name() == name {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,81 @@
+package socketio
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientOptions(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	srv := NewServer(nil)
+
+	handshakes := make(chan Handshake, 1)
+	srv.OnConnect("/", func(Conn) error { return nil })
+	srv.OnConnect("/chat", func(c Conn) error {
+		handshakes <- c.Handshake()
+		return nil
+	})
+
+	go func() {
+		_ = srv.Serve()
+	}()
+
+	// the sticky session cookie must be sent with the websocket upgrade.
+	upgraded := make(chan string, 1)
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("transport") == "websocket" {
+			if cookie, err := r.Cookie("sticky"); err == nil {
+				upgraded <- cookie.Value
+			}
+		} else {
+			http.SetCookie(w, &http.Cookie{Name: "sticky", Value: "node-1"})
+		}
+
+		srv.ServeHTTP(w, r)
+	}))
+	defer hs.Close()
+	defer srv.Close()
+
+	jar, err := cookiejar.New(nil)
+	must.NoError(err)
+
+	client, err := NewClient(hs.URL+"/chat", &ClientOptions{
+		EIO:    3,
+		Header: http.Header{"X-Token": []string{"token"}},
+		Query:  url.Values{"room": []string{"1"}},
+		Auth:   map[string]interface{}{"user": "bot"},
+		Jar:    jar,
+	})
+	must.NoError(err)
+	defer client.Close()
+
+	must.NoError(client.Connect())
+
+	select {
+	case h := <-handshakes:
+		should.Equal("token", h.Headers.Get("X-Token"))
+		should.Equal("1", h.Query.Get("room"))
+		should.Equal(map[string]interface{}{"user": "bot"}, h.Auth)
+	case <-time.After(5 * time.Second):
+		must.FailNow("not connected")
+	}
+
+	select {
+	case value := <-upgraded:
+		should.Equal("node-1", value)
+	case <-time.After(5 * time.Second):
+		must.FailNow("not upgraded")
+	}
+
+	_, err = NewClient(hs.URL, &ClientOptions{Transports: []string{"flash"}})
+	should.EqualError(err, `unknown transport "flash"`)
+}
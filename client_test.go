@@ -0,0 +1,313 @@
+package socketio
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientReconnection(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	srv := NewServer(nil)
+
+	conns := make(chan Conn, 2)
+	received := make(chan string, 1)
+	srv.OnConnect("/", func(Conn) error { return nil })
+	srv.OnConnect("/chat", func(c Conn) error {
+		conns <- c
+		return nil
+	})
+	srv.OnEvent("/chat", "msg", func(_ Conn, msg string) {
+		received <- msg
+	})
+
+	go func() {
+		_ = srv.Serve()
+	}()
+
+	hs := httptest.NewServer(srv)
+	defer hs.Close()
+	defer srv.Close()
+
+	client, err := NewClient(hs.URL+"/chat", &ClientOptions{EIO: 3})
+	must.NoError(err)
+	defer client.Close()
+
+	client.Reconnection(&ReconnectionOptions{Delay: 10 * time.Millisecond, Attempts: 3})
+
+	events := make(chan string, 8)
+	client.OnConnect(func(Conn) error {
+		events <- "connect"
+		return nil
+	})
+	client.OnEvent(reconnectAttemptEvent, func(_ Conn, attempt int) {
+		events <- reconnectAttemptEvent
+		should.Equal(1, attempt)
+	})
+	client.OnEvent(reconnectEvent, func(_ Conn, attempt int) {
+		events <- reconnectEvent
+		should.Equal(1, attempt)
+	})
+
+	must.NoError(client.Connect())
+
+	next := func() string {
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(5 * time.Second):
+			must.FailNow("no client event")
+		}
+		return ""
+	}
+
+	should.Equal("connect", next())
+	must.NoError((<-conns).Close())
+
+	should.Equal(reconnectAttemptEvent, next())
+	should.Equal(reconnectEvent, next())
+	should.Equal("connect", next())
+
+	conn := <-conns
+	// the server doesn't close the sessions, whose polling requests would
+	// keep the http server open.
+	defer conn.Close()
+
+	client.Emit("msg", "hello")
+
+	select {
+	case msg := <-received:
+		should.Equal("hello", msg)
+	case <-time.After(5 * time.Second):
+		must.FailNow("no message after reconnection")
+	}
+}
+
+func TestClientEmitWithAck(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	srv := NewServer(nil)
+
+	srv.OnConnect("/", func(Conn) error { return nil })
+	srv.OnEvent("/", "echo", func(_ Conn, msg string) string {
+		return msg
+	})
+	srv.OnEvent("/", "slow", func(Conn) {
+		time.Sleep(200 * time.Millisecond)
+	})
+
+	go func() {
+		_ = srv.Serve()
+	}()
+
+	hs := httptest.NewServer(srv)
+	defer hs.Close()
+	defer srv.Close()
+
+	client, err := NewClient(hs.URL, &ClientOptions{EIO: 3, Transports: []string{"websocket"}})
+	must.NoError(err)
+	defer client.Close()
+
+	incoming := make(chan string, 1)
+	client.OnAny(func(_ Conn, event string, args []interface{}) {
+		incoming <- event
+	})
+	outgoing := make(chan []interface{}, 3)
+	client.OnAnyOutgoing(func(_ Conn, event string, args []interface{}) {
+		outgoing <- append([]interface{}{event}, args...)
+	})
+
+	must.NoError(client.Connect())
+
+	type ack struct {
+		err   error
+		reply string
+	}
+	acks := make(chan ack, 2)
+	next := func() ack {
+		select {
+		case a := <-acks:
+			return a
+		case <-time.After(5 * time.Second):
+			must.FailNow("ack not called")
+		}
+		return ack{}
+	}
+
+	client.EmitWithAck(time.Second, "echo", func(err error, reply string) {
+		acks <- ack{err, reply}
+	}, "hello")
+	should.Equal(ack{nil, "hello"}, next())
+	should.Equal([]interface{}{"echo", "hello"}, <-outgoing)
+
+	replies := make(chan []interface{}, 1)
+	client.EmitWithAck(time.Second, "echo", func(err error, args ...interface{}) {
+		should.NoError(err)
+		replies <- args
+	}, "world")
+	select {
+	case args := <-replies:
+		should.Equal([]interface{}{"world"}, args)
+	case <-time.After(5 * time.Second):
+		must.FailNow("variadic ack not called")
+	}
+	should.Equal([]interface{}{"echo", "world"}, <-outgoing)
+
+	client.EmitWithAck(20*time.Millisecond, "slow", func(err error) {
+		acks <- ack{err: err}
+	})
+	should.Equal(ack{err: ErrAckTimeout}, next())
+
+	client.EmitWithAck(0, "slow", func(err error) {
+		acks <- ack{err: err}
+	})
+	time.Sleep(20 * time.Millisecond)
+	must.NoError(client.Close())
+	should.Equal(ack{err: ErrAckDisconnected}, next())
+
+	select {
+	case event := <-incoming:
+		must.FailNow("unexpected incoming event", event)
+	default:
+	}
+}
+
+func TestClientEIO4(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	received := make(chan string, 8)
+
+	// a server of socket.io v5 over engine.io v4.
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		should.Equal("4", r.URL.Query().Get("EIO"))
+
+		c, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		must.NoError(err)
+		defer c.Close()
+
+		write := func(msg string) {
+			must.NoError(c.WriteMessage(websocket.TextMessage, []byte(msg)))
+		}
+
+		write(`0{"sid":"engine","upgrades":[],"pingInterval":25000,"pingTimeout":5000,"maxPayload":1000000}`)
+
+		for {
+			_, b, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- strings.TrimSpace(string(b))
+
+			switch msg := strings.TrimSpace(string(b)); {
+			case msg == "40":
+				write(`40{"sid":"root"}`)
+			case strings.HasPrefix(msg, "40/chat,"):
+				write(`40/chat,{"sid":"chat"}`)
+				write("2")
+				write(`42/chat,["msg","hi"]`)
+			case strings.HasPrefix(msg, "40/admin,"):
+				write(`44/admin,{"message":"forbidden"}`)
+			}
+		}
+	}))
+	defer hs.Close()
+
+	client, err := NewClient(hs.URL+"/chat", &ClientOptions{
+		Transports: []string{"websocket"},
+		Auth:       map[string]interface{}{"token": "abc"},
+	})
+	must.NoError(err)
+	defer client.Close()
+
+	ids := make(chan string, 1)
+	client.OnConnect(func(c Conn) error {
+		ids <- c.ID()
+		return nil
+	})
+	msgs := make(chan string, 1)
+	client.OnEvent("msg", func(_ Conn, msg string) {
+		msgs <- msg
+	})
+	errs := make(chan string, 1)
+	admin := client.Socket("/admin")
+	admin.OnError(func(_ Conn, err error) {
+		errs <- err.Error()
+	})
+
+	must.NoError(client.Connect())
+	must.NoError(admin.Connect())
+
+	next := func(c chan string) string {
+		select {
+		case v := <-c:
+			return v
+		case <-time.After(5 * time.Second):
+			must.FailNow("timeout")
+		}
+		return ""
+	}
+
+	should.Equal("chat", next(ids))
+	should.Equal("hi", next(msgs))
+	should.Equal("forbidden", next(errs))
+
+	var packets []string
+	for len(packets) < 4 {
+		packets = append(packets, next(received))
+	}
+	should.ElementsMatch([]string{"40", `40/chat,{"token":"abc"}`, "3", `40/admin,{"token":"abc"}`}, packets)
+}
+
+func TestClientPingTimeout(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	// the server connects the namespaces, and never pings.
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		must.NoError(err)
+		defer c.Close()
+
+		open := `0{"sid":"silent","upgrades":[],"pingInterval":50,"pingTimeout":50}`
+		must.NoError(c.WriteMessage(websocket.TextMessage, []byte(open)))
+
+		for {
+			_, b, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if msg := string(b); strings.HasPrefix(msg, "40") {
+				must.NoError(c.WriteMessage(websocket.TextMessage, []byte(`40{"sid":"root"}`)))
+			}
+		}
+	}))
+	defer hs.Close()
+
+	client, err := NewClient(hs.URL, &ClientOptions{Transports: []string{"websocket"}})
+	must.NoError(err)
+	defer client.Close()
+
+	reasons := make(chan string, 1)
+	client.OnDisconnect(func(_ Conn, reason string) {
+		reasons <- reason
+	})
+
+	must.NoError(client.Connect())
+
+	select {
+	case reason := <-reasons:
+		should.Equal("ping timeout", reason)
+	case <-time.After(5 * time.Second):
+		must.FailNow("not disconnected")
+	}
+}
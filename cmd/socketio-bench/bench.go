@@ -0,0 +1,297 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// The events of the benchmark, which the target server handles like the
+// self-hosted one: it acks echoEvent with its payload, and broadcasts the
+// payload of broadcastEvent to the namespace with broadcastEvent.
+const (
+	echoEvent      = "bench:echo"
+	broadcastEvent = "bench:broadcast"
+)
+
+// quiet is the logger of the clients and of the self-hosted server, whose
+// errors, like the ones of closing the many clients, would bury the report.
+var quiet = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// payload is the arg of the events, whose latency is measured from Sent.
+type payload struct {
+	Sent int64  `json:"sent"`
+	Data string `json:"data"`
+}
+
+func (p payload) latency() time.Duration {
+	return time.Since(time.Unix(0, p.Sent))
+}
+
+// config is the configuration of a benchmark.
+type config struct {
+	// URL is the url of the server, whose path is the engine.io path.
+	URL       string
+	Namespace string
+	EIO       int
+
+	// Clients are connected over RampUp, then emit for Duration.
+	Clients  int
+	RampUp   time.Duration
+	Duration time.Duration
+	// Mix is the transport of the clients, the client i using
+	// Mix[i%len(Mix)].
+	Mix []string
+
+	// EmitRate is the emits per second of each client, of EmitSize bytes.
+	EmitRate float64
+	EmitSize int
+	// BroadcastRate is the broadcasts per second of all the clients.
+	BroadcastRate float64
+
+	// Timeout is the timeout of the connects and of the acks.
+	Timeout time.Duration
+}
+
+// parseMix parses the transport mix, like websocket=3,polling=1 for a quarter
+// of polling clients.
+func parseMix(s string) ([]string, error) {
+	var mix []string
+
+	for _, part := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			weight = "1"
+		}
+
+		if name != "polling" && name != "websocket" {
+			return nil, fmt.Errorf("unknown transport %q", name)
+		}
+
+		n, err := strconv.Atoi(weight)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid weight of %s: %q", name, weight)
+		}
+
+		for i := 0; i < n; i++ {
+			mix = append(mix, name)
+		}
+	}
+
+	if len(mix) == 0 {
+		return nil, errors.New("empty transport mix")
+	}
+
+	return mix, nil
+}
+
+// bench runs the clients of a benchmark, and records their latencies.
+type bench struct {
+	cfg  config
+	data string
+
+	connect   latencies
+	ack       latencies
+	broadcast latencies
+
+	stop chan struct{}
+
+	socketsMu sync.Mutex
+	sockets   []*socketio.Socket
+}
+
+// run runs the benchmark of cfg, and reports its latencies once the clients
+// are closed.
+func run(cfg config) report {
+	b := &bench{
+		cfg:  cfg,
+		data: strings.Repeat("x", cfg.EmitSize),
+		stop: make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < cfg.Clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			if b.sleep(cfg.RampUp * time.Duration(i) / time.Duration(cfg.Clients)) {
+				b.client(cfg.Mix[i%len(cfg.Mix)])
+			}
+		}(i)
+	}
+
+	if cfg.BroadcastRate > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.broadcaster()
+		}()
+	}
+
+	time.Sleep(cfg.RampUp + cfg.Duration)
+	close(b.stop)
+	wg.Wait()
+
+	return report{
+		Connect:   b.connect.summary(),
+		Ack:       b.ack.summary(),
+		Broadcast: b.broadcast.summary(),
+	}
+}
+
+// sleep sleeps for d, and tells if the benchmark isn't stopped.
+func (b *bench) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-b.stop:
+		return false
+	}
+}
+
+func (b *bench) stopped() bool {
+	select {
+	case <-b.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// client connects a client with transport, and emits until the benchmark is
+// stopped.
+func (b *bench) client(transport string) {
+	m, err := socketio.NewManager(b.cfg.URL, &socketio.ClientOptions{
+		Transports: []string{transport},
+		EIO:        b.cfg.EIO,
+		Logger:     quiet,
+	})
+	if err != nil {
+		b.connect.fail()
+		return
+	}
+	defer m.Close()
+
+	s := m.Socket(b.cfg.Namespace)
+
+	connected := make(chan error, 1)
+	report := func(err error) {
+		select {
+		case connected <- err:
+		default:
+		}
+	}
+
+	s.OnConnect(func(socketio.Conn) error {
+		report(nil)
+		return nil
+	})
+	s.OnError(func(_ socketio.Conn, err error) {
+		report(err)
+	})
+	s.OnEvent(broadcastEvent, func(_ socketio.Conn, p payload) {
+		b.broadcast.add(p.latency())
+	})
+
+	start := time.Now()
+	if err := s.Connect(); err != nil {
+		b.connect.fail()
+		return
+	}
+
+	select {
+	case err := <-connected:
+		if err != nil {
+			b.connect.fail()
+			return
+		}
+	case <-time.After(b.cfg.Timeout):
+		b.connect.fail()
+		return
+	case <-b.stop:
+		return
+	}
+
+	b.connect.add(time.Since(start))
+	b.addSocket(s)
+
+	if b.cfg.EmitRate <= 0 {
+		<-b.stop
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / b.cfg.EmitRate))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.emit(s)
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// emit emits echoEvent with s, and records the latency of its ack.
+func (b *bench) emit(s *socketio.Socket) {
+	s.EmitWithAck(b.cfg.Timeout, echoEvent, func(err error, p payload) {
+		switch {
+		case err == nil:
+			b.ack.add(p.latency())
+		// the acks pending once stopped are lost with the closed clients.
+		case errors.Is(err, socketio.ErrAckDisconnected) && b.stopped():
+		default:
+			b.ack.fail()
+		}
+	}, b.payload())
+}
+
+func (b *bench) payload() payload {
+	return payload{Sent: time.Now().UnixNano(), Data: b.data}
+}
+
+func (b *bench) addSocket(s *socketio.Socket) {
+	b.socketsMu.Lock()
+	defer b.socketsMu.Unlock()
+
+	b.sockets = append(b.sockets, s)
+}
+
+// broadcaster emits broadcastEvent with the connected sockets in turn, at
+// BroadcastRate.
+func (b *bench) broadcaster() {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / b.cfg.BroadcastRate))
+	defer ticker.Stop()
+
+	for i := 0; ; i++ {
+		select {
+		case <-ticker.C:
+		case <-b.stop:
+			return
+		}
+
+		b.socketsMu.Lock()
+		var s *socketio.Socket
+		if len(b.sockets) > 0 {
+			s = b.sockets[i%len(b.sockets)]
+		}
+		b.socketsMu.Unlock()
+
+		if s != nil {
+			s.Emit(broadcastEvent, b.payload())
+		}
+	}
+}
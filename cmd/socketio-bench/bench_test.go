@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMix(t *testing.T) {
+	should := assert.New(t)
+
+	mix, err := parseMix("websocket=2, polling")
+	should.NoError(err)
+	should.Equal([]string{"websocket", "websocket", "polling"}, mix)
+
+	_, err = parseMix("quic=1")
+	should.Error(err)
+	_, err = parseMix("websocket=x")
+	should.Error(err)
+	_, err = parseMix("websocket=0")
+	should.Error(err)
+}
+
+func TestPercentile(t *testing.T) {
+	should := assert.New(t)
+
+	var l latencies
+	for i := 100; i >= 1; i-- {
+		l.add(time.Duration(i) * time.Millisecond)
+	}
+	l.fail()
+
+	should.Equal(summary{
+		Count:  100,
+		Errors: 1,
+		P50:    50 * time.Millisecond,
+		P90:    90 * time.Millisecond,
+		P99:    99 * time.Millisecond,
+		Max:    100 * time.Millisecond,
+	}, l.summary())
+
+	should.Equal(summary{}, (&latencies{}).summary())
+}
+
+func TestRunSelfHosted(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	url, closeServer, err := serve("/")
+	must.NoError(err)
+	defer closeServer()
+
+	r := run(config{
+		URL:           url,
+		Namespace:     "/",
+		EIO:           3,
+		Clients:       4,
+		RampUp:        40 * time.Millisecond,
+		Duration:      300 * time.Millisecond,
+		Mix:           []string{"websocket", "polling"},
+		EmitRate:      20,
+		EmitSize:      16,
+		BroadcastRate: 10,
+		Timeout:       time.Second,
+	})
+
+	should.Equal(4, r.Connect.Count)
+	should.Zero(r.Connect.Errors)
+	should.NotZero(r.Ack.Count)
+	should.Zero(r.Ack.Errors)
+	should.NotZero(r.Broadcast.Count)
+
+	var out bytes.Buffer
+	must.NoError(r.print(&out))
+	should.Contains(out.String(), "broadcast")
+}
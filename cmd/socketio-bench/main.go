@@ -0,0 +1,79 @@
+// Command socketio-bench load tests a socket.io server with many Go clients.
+// It connects the clients over a ramp-up, makes them emit at a rate, and
+// reports the percentiles of the connect time, of the ack round-trip and of
+// the broadcast fan-out latency.
+//
+//	socketio-bench -clients 1000 -ramp 10s -duration 30s http://localhost:8000
+//	socketio-bench -self -clients 1000
+//
+// The target server acks bench:echo with its arg, and broadcasts the arg of
+// bench:broadcast to the namespace with bench:broadcast. With -self, an
+// in-process go-socket.io server does so for reproducible local benchmarks.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/googollee/go-socket.io/logger"
+)
+
+func main() {
+	var cfg config
+
+	self := flag.Bool("self", false, "benchmark an in-process server instead of the url")
+	mix := flag.String("transports", "websocket", "transport mix, like websocket=3,polling=1")
+	flag.StringVar(&cfg.Namespace, "namespace", "/", "namespace of the clients")
+	flag.IntVar(&cfg.EIO, "eio", 4, "engine.io protocol version, 3 for socket.io v2 servers")
+	flag.IntVar(&cfg.Clients, "clients", 100, "number of clients")
+	flag.DurationVar(&cfg.RampUp, "ramp", 5*time.Second, "duration over which the clients connect")
+	flag.DurationVar(&cfg.Duration, "duration", 10*time.Second, "duration of the emits once ramped up")
+	flag.Float64Var(&cfg.EmitRate, "rate", 1, "emits per second of each client")
+	flag.IntVar(&cfg.EmitSize, "size", 64, "bytes of the payload of the emits")
+	flag.Float64Var(&cfg.BroadcastRate, "broadcast-rate", 1, "broadcasts per second of all the clients")
+	flag.DurationVar(&cfg.Timeout, "timeout", 10*time.Second, "timeout of the connects and of the acks")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <url>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// the engine.io clients log with the default logger.
+	logger.Log = quiet
+
+	var err error
+	if cfg.Mix, err = parseMix(*mix); err != nil {
+		log.Fatalf("invalid transports: %s", err)
+	}
+	if cfg.Clients <= 0 {
+		log.Fatal("no clients")
+	}
+
+	switch {
+	case *self && flag.NArg() == 0:
+		url, closeServer, err := serve(cfg.Namespace)
+		if err != nil {
+			log.Fatalf("serve: %s", err)
+		}
+		defer closeServer()
+
+		cfg.URL = url
+		// the go-socket.io server speaks engine.io v3.
+		cfg.EIO = 3
+	case !*self && flag.NArg() == 1:
+		cfg.URL = flag.Arg(0)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	log.Printf("%d clients on %s, ramping up for %s, running for %s", cfg.Clients, cfg.URL, cfg.RampUp, cfg.Duration)
+
+	if err := run(cfg).print(os.Stdout); err != nil {
+		log.Fatalf("print report: %s", err)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net"
+	"net/http"
+
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// serve serves the self-hosted server of the benchmark events on a local
+// port, and returns its url with the func closing it.
+func serve(namespace string) (string, func(), error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+
+	srv := socketio.NewServer(nil)
+	srv.SetLogger(quiet)
+
+	srv.OnConnect(namespace, func(socketio.Conn) error { return nil })
+	srv.OnEvent(namespace, echoEvent, func(_ socketio.Conn, p payload) payload {
+		return p
+	})
+	srv.OnEvent(namespace, broadcastEvent, func(_ socketio.Conn, p payload) {
+		srv.BroadcastToNamespace(namespace, broadcastEvent, p)
+	})
+
+	go func() {
+		_ = srv.Serve()
+	}()
+
+	hs := &http.Server{Handler: srv}
+	go func() {
+		_ = hs.Serve(l)
+	}()
+
+	return "http://" + l.Addr().String(), func() {
+		_ = hs.Close()
+		_ = srv.Close()
+	}, nil
+}
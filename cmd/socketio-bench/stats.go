@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// latencies records the latencies of an operation, and its failures.
+type latencies struct {
+	mu     sync.Mutex
+	values []time.Duration
+	errors int
+}
+
+func (l *latencies) add(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.values = append(l.values, d)
+}
+
+func (l *latencies) fail() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.errors++
+}
+
+// summary is the count and the percentiles of latencies.
+type summary struct {
+	Count  int
+	Errors int
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+	Max    time.Duration
+}
+
+func (l *latencies) summary() summary {
+	l.mu.Lock()
+	values := append([]time.Duration(nil), l.values...)
+	s := summary{Count: len(values), Errors: l.errors}
+	l.mu.Unlock()
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	s.P50 = percentile(values, 50)
+	s.P90 = percentile(values, 90)
+	s.P99 = percentile(values, 99)
+	s.Max = percentile(values, 100)
+
+	return s
+}
+
+// percentile returns the nearest-rank percentile p of the sorted values, 0 if
+// there are none.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+
+	return sorted[rank]
+}
+
+// report is the result of a benchmark.
+type report struct {
+	Connect   summary
+	Ack       summary
+	Broadcast summary
+}
+
+func (r report) print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "\tcount\terrors\tp50\tp90\tp99\tmax\t")
+	for _, row := range []struct {
+		name string
+		s    summary
+	}{
+		{"connect", r.Connect},
+		{"ack", r.Ack},
+		{"broadcast", r.Broadcast},
+	} {
+		s := row.s
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t\n", row.name, s.Count, s.Errors,
+			round(s.P50), round(s.P90), round(s.P99), round(s.Max))
+	}
+
+	return tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
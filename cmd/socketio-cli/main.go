@@ -0,0 +1,119 @@
+// Command socketio-cli connects to a socket.io server to debug it. It prints
+// the events of the server as JSON lines, and emits the events typed in its
+// REPL, or read from a script.
+//
+//	socketio-cli -namespace /chat -auth '{"token":"abc"}' http://localhost:8000
+//
+// The commands are:
+//
+//	emit <event> [args...]   emits the event, and prints its ack
+//	<event> [args...]        like emit, as in join lobby
+//	ns <namespace>           connects the namespace, and uses it
+//	disconnect               disconnects the namespace
+//	wait                     waits for the pending acks
+//	sleep <duration>         sleeps, like sleep 500ms
+//	quit                     quits
+//
+// The args are JSON values separated by spaces, the words which aren't JSON
+// being strings.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// headerFlag is the -header flag, repeated for each header.
+type headerFlag http.Header
+
+func (h headerFlag) String() string {
+	return fmt.Sprint(http.Header(h))
+}
+
+func (h headerFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("header %q isn't like Key: Value", value)
+	}
+
+	http.Header(h).Add(strings.TrimSpace(key), strings.TrimSpace(val))
+	return nil
+}
+
+func main() {
+	header := headerFlag{}
+
+	namespace := flag.String("namespace", "/", "namespace to connect")
+	transports := flag.String("transports", "polling,websocket", "transports to dial, comma separated")
+	eio := flag.Int("eio", 4, "engine.io protocol version, 3 for socket.io v2 servers")
+	auth := flag.String("auth", "", "auth payload of the connect packets, as JSON")
+	script := flag.String("script", "", "file of the commands to run instead of stdin")
+	timeout := flag.Duration("timeout", 5*time.Second, "timeout of the connects and of the acks")
+	flag.Var(header, "header", "header of the requests, like 'Key: Value', repeatable")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <url>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := &socketio.ClientOptions{
+		Transports: strings.Split(*transports, ","),
+		EIO:        *eio,
+		Header:     http.Header(header),
+		// the commands of a script don't wait for the namespaces to connect.
+		SendBuffer: &socketio.SendBufferOptions{},
+	}
+
+	if *auth != "" {
+		if err := json.Unmarshal([]byte(*auth), &opts.Auth); err != nil {
+			log.Fatalf("invalid auth: %s", err)
+		}
+	}
+
+	manager, err := socketio.NewManager(flag.Arg(0), opts)
+	if err != nil {
+		log.Fatalf("new manager: %s", err)
+	}
+
+	var in io.Reader = os.Stdin
+	if *script != "" {
+		f, err := os.Open(*script)
+		if err != nil {
+			log.Fatalf("open script: %s", err)
+		}
+		defer f.Close()
+
+		in = f
+	}
+
+	c := newCLI(manager, os.Stdout, *timeout)
+
+	if err := c.use(*namespace); err != nil {
+		_ = manager.Close()
+		log.Fatalf("connect %s: %s", *namespace, err)
+	}
+
+	err = c.run(in)
+
+	c.wait()
+	_ = manager.Close()
+
+	if err != nil {
+		log.Fatalf("read commands: %s", err)
+	}
+}
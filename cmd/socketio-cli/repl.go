@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// errQuit stops the REPL.
+var errQuit = errors.New("quit")
+
+// record is a JSON line of the output.
+type record struct {
+	Type      string        `json:"type"`
+	Namespace string        `json:"namespace,omitempty"`
+	Event     string        `json:"event,omitempty"`
+	ID        string        `json:"id,omitempty"`
+	Args      []interface{} `json:"args,omitempty"`
+	Reason    string        `json:"reason,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// cli runs the commands with the sockets of a manager, and prints what
+// happens to them.
+type cli struct {
+	manager *socketio.Manager
+	timeout time.Duration
+
+	outMu sync.Mutex
+	out   *json.Encoder
+
+	// socket is the socket of the namespace used by the commands, only
+	// changed by run.
+	socket *socketio.Socket
+	// ready gets the results of the connects of the namespaces.
+	ready map[string]chan error
+	// acks are the pending acks.
+	acks sync.WaitGroup
+}
+
+func newCLI(manager *socketio.Manager, out io.Writer, timeout time.Duration) *cli {
+	return &cli{
+		manager: manager,
+		timeout: timeout,
+		out:     json.NewEncoder(out),
+		ready:   make(map[string]chan error),
+	}
+}
+
+func (c *cli) print(r record) {
+	c.outMu.Lock()
+	defer c.outMu.Unlock()
+
+	_ = c.out.Encode(r)
+}
+
+// use connects the namespace, and uses it for the next commands once it's
+// connected.
+func (c *cli) use(namespace string) error {
+	s := c.manager.Socket(namespace)
+
+	ready, ok := c.ready[s.Namespace()]
+	if !ok {
+		ready = c.watch(s)
+		c.ready[s.Namespace()] = ready
+	}
+
+	if !s.Connected() {
+		if err := s.Connect(); err != nil {
+			return err
+		}
+
+		select {
+		case err := <-ready:
+			if err != nil {
+				return err
+			}
+		case <-time.After(c.timeout):
+			return fmt.Errorf("not connected after %s", c.timeout)
+		}
+	}
+
+	c.socket = s
+	return nil
+}
+
+// watch prints the events of s, and returns the channel of the results of its
+// connects.
+func (c *cli) watch(s *socketio.Socket) chan error {
+	ns := namespaceName(s)
+	ready := make(chan error, 1)
+
+	report := func(err error) {
+		select {
+		case ready <- err:
+		default:
+		}
+	}
+
+	s.OnConnect(func(conn socketio.Conn) error {
+		c.print(record{Type: "connect", Namespace: ns, ID: conn.ID()})
+		report(nil)
+		return nil
+	})
+	s.OnDisconnect(func(_ socketio.Conn, reason string) {
+		c.print(record{Type: "disconnect", Namespace: ns, Reason: reason})
+	})
+	s.OnError(func(_ socketio.Conn, err error) {
+		c.print(record{Type: "error", Namespace: ns, Error: err.Error()})
+		report(err)
+	})
+	s.OnAny(func(_ socketio.Conn, event string, args []interface{}) {
+		c.print(record{Type: "event", Namespace: ns, Event: event, Args: args})
+	})
+
+	return ready
+}
+
+// run runs the commands read from r, one per line, until quit. The lines
+// starting with # are comments.
+func (c *cli) run(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		err := c.exec(line)
+		if errors.Is(err, errQuit) {
+			return nil
+		}
+		if err != nil {
+			c.print(record{Type: "error", Namespace: namespaceName(c.socket), Error: err.Error()})
+		}
+	}
+
+	return scanner.Err()
+}
+
+// exec runs the command of line.
+func (c *cli) exec(line string) error {
+	cmd, rest := cutWord(line)
+
+	switch cmd {
+	case "quit", "exit":
+		return errQuit
+	case "ns":
+		if rest == "" {
+			return errors.New("usage: ns <namespace>")
+		}
+		return c.use(rest)
+	case "disconnect":
+		return c.socket.Disconnect()
+	case "wait":
+		c.wait()
+		return nil
+	case "sleep":
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return err
+		}
+		time.Sleep(d)
+		return nil
+	case "emit":
+		cmd, rest = cutWord(rest)
+		if cmd == "" {
+			return errors.New("usage: emit <event> [args...]")
+		}
+	}
+
+	args, err := parseArgs(rest)
+	if err != nil {
+		return err
+	}
+
+	c.emit(cmd, args)
+	return nil
+}
+
+// emit emits the event with args, and prints its ack, or the error of the
+// ack once timeout elapses.
+func (c *cli) emit(event string, args []interface{}) {
+	ns := namespaceName(c.socket)
+
+	c.acks.Add(1)
+	c.socket.EmitWithAck(c.timeout, event, func(err error, args ...interface{}) {
+		defer c.acks.Done()
+
+		r := record{Type: "ack", Namespace: ns, Event: event, Args: args}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		c.print(r)
+	}, args...)
+}
+
+// wait waits for the pending acks.
+func (c *cli) wait() {
+	c.acks.Wait()
+}
+
+// namespaceName returns the namespace of s, / for the root namespace.
+func namespaceName(s *socketio.Socket) string {
+	if s.Namespace() == "" {
+		return "/"
+	}
+	return s.Namespace()
+}
+
+// parseArgs parses the args separated by spaces, which are JSON values, or
+// strings for the words which aren't JSON.
+func parseArgs(s string) ([]interface{}, error) {
+	var args []interface{}
+
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		dec := json.NewDecoder(strings.NewReader(s))
+
+		var v interface{}
+		if err := dec.Decode(&v); err == nil {
+			n := int(dec.InputOffset())
+			if n == len(s) || unicode.IsSpace(rune(s[n])) {
+				args = append(args, v)
+				s = s[n:]
+				continue
+			}
+		}
+
+		if s[0] == '{' || s[0] == '[' || s[0] == '"' {
+			return nil, fmt.Errorf("invalid JSON arg: %s", s)
+		}
+
+		var word string
+		word, s = cutWord(s)
+		args = append(args, word)
+	}
+
+	return args, nil
+}
+
+// cutWord cuts the first word of s, and returns it with the rest of s.
+func cutWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+
+	return s[:i], strings.TrimSpace(s[i:])
+}
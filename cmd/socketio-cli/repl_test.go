@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	socketio "github.com/googollee/go-socket.io"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []interface{}
+	}{
+		{"", nil},
+		{"lobby", []interface{}{"lobby"}},
+		{`{"a": [1, 2]} "b c" 3 true null`, []interface{}{map[string]interface{}{"a": []interface{}{1.0, 2.0}}, "b c", 3.0, true, nil}},
+		{"12abc  x", []interface{}{"12abc", "x"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			got, err := parseArgs(test.in)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+
+	_, err := parseArgs(`{"a": `)
+	assert.Error(t, err)
+}
+
+func TestCLIScript(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	srv := socketio.NewServer(nil)
+
+	srv.OnConnect("/", func(socketio.Conn) error { return nil })
+	srv.OnConnect("/chat", func(socketio.Conn) error { return nil })
+	srv.OnEvent("/", "echo", func(_ socketio.Conn, msg map[string]interface{}) map[string]interface{} {
+		return msg
+	})
+	srv.OnEvent("/chat", "join", func(s socketio.Conn, room string) string {
+		s.Join(room)
+		s.Emit("joined", room)
+		return "ok"
+	})
+
+	go func() {
+		_ = srv.Serve()
+	}()
+
+	hs := httptest.NewServer(srv)
+	defer hs.Close()
+	defer srv.Close()
+
+	manager, err := socketio.NewManager(hs.URL, &socketio.ClientOptions{
+		EIO:        3,
+		Transports: []string{"websocket"},
+		SendBuffer: &socketio.SendBufferOptions{},
+	})
+	must.NoError(err)
+
+	var out bytes.Buffer
+	c := newCLI(manager, &out, time.Second)
+	must.NoError(c.use("/"))
+
+	script := `
+# echo an object
+emit echo {"n": 1}
+wait
+ns /chat
+join lobby
+wait
+leave lobby
+quit
+emit echo {"n": 2}
+`
+	must.NoError(c.run(strings.NewReader(script)))
+	c.wait()
+	must.NoError(manager.Close())
+
+	c.outMu.Lock()
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	c.outMu.Unlock()
+
+	var records []record
+	for _, line := range lines {
+		var r record
+		must.NoError(json.Unmarshal([]byte(line), &r))
+		r.ID = ""
+		records = append(records, r)
+	}
+
+	should.Contains(records, record{Type: "connect", Namespace: "/"})
+	should.Contains(records, record{Type: "ack", Namespace: "/", Event: "echo", Args: []interface{}{map[string]interface{}{"n": 1.0}}})
+	should.Contains(records, record{Type: "connect", Namespace: "/chat"})
+	should.Contains(records, record{Type: "event", Namespace: "/chat", Event: "joined", Args: []interface{}{"lobby"}})
+	should.Contains(records, record{Type: "ack", Namespace: "/chat", Event: "join", Args: []interface{}{"ok"}})
+	// the server acks the events without handler too.
+	should.Contains(records, record{Type: "ack", Namespace: "/chat", Event: "leave"})
+	should.Contains(records, record{Type: "disconnect", Namespace: "/chat", Reason: "client namespace disconnect"})
+	should.NotContains(records, record{Type: "ack", Namespace: "/", Event: "echo", Args: []interface{}{map[string]interface{}{"n": 2.0}}})
+}
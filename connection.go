@@ -2,6 +2,7 @@ package socketio
 
 import (
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
@@ -9,6 +10,8 @@ import (
 	"sync"
 
 	"github.com/googollee/go-socket.io/engineio"
+	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/metrics"
 	"github.com/googollee/go-socket.io/parser"
 )
 
@@ -23,6 +26,11 @@ type Conn interface {
 	LocalAddr() net.Addr
 	RemoteAddr() net.Addr
 	RemoteHeader() http.Header
+	// Handshake returns the details of the handshake of this connection.
+	Handshake() Handshake
+
+	// SessionData returns the store shared by all namespaces of the session.
+	SessionData() *Store
 }
 
 type conn struct {
@@ -31,9 +39,15 @@ type conn struct {
 	id         uint64
 	handlers   *namespaceHandlers
 	namespaces *namespaces
+	data       *Store
+	metrics    metrics.Metrics
+	tracer     *tracer
+	logger     *slog.Logger
+	admin      *admin
+	streams    *streams
 
-	encoder *parser.Encoder
-	decoder *parser.Decoder
+	encoder parser.PacketEncoder
+	decoder parser.PacketDecoder
 
 	writeChan chan parser.Payload
 	errorChan chan error
@@ -42,29 +56,48 @@ type conn struct {
 	closeOnce sync.Once
 }
 
-func newConn(engineConn engineio.Conn, handlers *namespaceHandlers) *conn {
+func newConn(engineConn engineio.Conn, handlers *namespaceHandlers, p parser.Parser, m metrics.Metrics, t *tracer, l *slog.Logger) *conn {
 	return &conn{
 		Conn:       engineConn,
-		encoder:    parser.NewEncoder(engineConn),
-		decoder:    parser.NewDecoder(engineConn),
+		encoder:    p.NewEncoder(engineConn),
+		decoder:    p.NewDecoder(engineConn),
 		errorChan:  make(chan error),
 		writeChan:  make(chan parser.Payload),
 		quitChan:   make(chan struct{}),
 		handlers:   handlers,
 		namespaces: newNamespaces(),
+		data:       newStore(),
+		streams:    newStreams(),
+		metrics:    m,
+		tracer:     t,
+		logger:     l.With(logger.SIDKey, engineConn.ID()),
 	}
 }
 
+// log gives the logger of the connection for namespace.
+func (c *conn) log(namespace string) *slog.Logger {
+	return c.logger.With(logger.TransportKey, c.Transport(), logger.NamespaceKey, namespaceName(namespace))
+}
+
 func (c *conn) Close() error {
+	return c.closeWith(clientDisconnectMsg)
+}
+
+// closeWith closes the connection, whose namespaces are disconnected with
+// reason.
+func (c *conn) closeWith(reason string) error {
 	var err error
 
 	c.closeOnce.Do(func() {
 		// for each namespace, leave all rooms, and call the disconnect handler.
 		c.namespaces.Range(func(ns string, nc *namespaceConn) {
 			if nh, _ := c.handlers.Get(ns); nh != nil && nh.onDisconnect != nil {
-				nh.onDisconnect(nc, clientDisconnectMsg)
+				nh.onDisconnect(nc, reason)
 			}
+			c.admin.socketDisconnected(nc, reason)
+			nc.failAcks(ErrAckDisconnected)
 			nc.LeaveAll()
+			c.metrics.SocketDisconnected(namespaceName(ns))
 		})
 		err = c.Conn.Close()
 
@@ -74,6 +107,10 @@ func (c *conn) Close() error {
 	return err
 }
 
+func (c *conn) SessionData() *Store {
+	return c.data
+}
+
 func (c *conn) connect() error {
 	rootHandler, ok := c.handlers.Get(rootNamespace)
 	if !ok {
@@ -82,13 +119,10 @@ func (c *conn) connect() error {
 
 	root := newNamespaceConn(c, aliasRootNamespace, rootHandler.broadcast)
 	c.namespaces.Set(rootNamespace, root)
+	c.metrics.SocketConnected(aliasRootNamespace)
 
 	root.Join(root.Conn.ID())
 
-	c.namespaces.Range(func(ns string, nc *namespaceConn) {
-		nc.SetContext(c.Conn.Context())
-	})
-
 	header := parser.Header{
 		Type: parser.Connect,
 	}
@@ -99,10 +133,13 @@ func (c *conn) connect() error {
 
 	handler, ok := c.handlers.Get(header.Namespace)
 	if ok {
-		_, err := handler.dispatch(root, header)
-		return err
+		if _, err := handler.dispatch(root, header); err != nil {
+			return err
+		}
 	}
 
+	c.admin.socketConnected(root)
+
 	return nil
 }
 
@@ -113,6 +150,12 @@ func (c *conn) nextID() uint64 {
 }
 
 func (c *conn) write(header parser.Header, args ...reflect.Value) {
+	c.writeWith(true, header, args...)
+}
+
+// writeWith writes the packet, compressed by the transport if compress is
+// true and the transport compresses frames.
+func (c *conn) writeWith(compress bool, header parser.Header, args ...reflect.Value) {
 	data := make([]interface{}, len(args))
 
 	for i := range data {
@@ -120,8 +163,13 @@ func (c *conn) write(header parser.Header, args ...reflect.Value) {
 	}
 
 	pkg := parser.Payload{
-		Header: header,
-		Data:   data,
+		Header:       header,
+		Data:         data,
+		Uncompressed: !compress,
+	}
+
+	if header.Type == parser.Event && len(args) > 0 {
+		c.metrics.EventSent(namespaceName(header.Namespace), args[0].String())
 	}
 
 	select {
@@ -131,6 +179,24 @@ func (c *conn) write(header parser.Header, args ...reflect.Value) {
 	}
 }
 
+func (c *conn) encode(pkg parser.Payload) error {
+	args := []interface{}{pkg.Data}
+
+	switch {
+	case pkg.Data == nil:
+		args = nil
+	case pkg.Header.Type == parser.Connect && len(pkg.Data) == 1:
+		// the data of the connect packets of clients is their auth.
+		args = pkg.Data
+	}
+
+	if pkg.Uncompressed {
+		return c.encoder.EncodeUncompressed(pkg.Header, args...)
+	}
+
+	return c.encoder.Encode(pkg.Header, args...)
+}
+
 func (c *conn) onError(namespace string, err error) {
 	select {
 	case c.errorChan <- newErrorMessage(namespace, err):
@@ -139,6 +205,15 @@ func (c *conn) onError(namespace string, err error) {
 	}
 }
 
+// namespaceName gives the name of nsp as clients see it.
+func namespaceName(nsp string) string {
+	if nsp == rootNamespace {
+		return aliasRootNamespace
+	}
+
+	return nsp
+}
+
 func (c *conn) namespace(nsp string) *namespaceHandler {
 	handler, _ := c.handlers.Get(nsp)
 	return handler
@@ -1,9 +1,15 @@
 package socketio
 
 import (
-	"log"
+	"errors"
+	"reflect"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/metrics"
 	"github.com/googollee/go-socket.io/parser"
 )
 
@@ -18,6 +24,10 @@ func ackPacketHandler(c *conn, header parser.Header) error {
 
 	defer nc.ack.Delete(header.ID)
 
+	if sent, ok := nc.ackTime.LoadAndDelete(header.ID); ok {
+		c.metrics.AckLatency(namespaceName(header.Namespace), time.Since(sent.(time.Time)))
+	}
+
 	rawFunc, ok := nc.ack.Load(header.ID)
 	if !ok {
 		// No function for this ack, but still need to read body
@@ -27,22 +37,33 @@ func ackPacketHandler(c *conn, header parser.Header) error {
 	handler, ok := rawFunc.(*funcHandler)
 	if !ok {
 		// This should never get here and would be solved with generic sync.Map
-		logger.Info("Incorrect Ack functinxo type")
+		c.log(header.Namespace).Warn("incorrect ack function type")
 		handler = emtpyFH // keep going
 	}
 
+	_, span := c.tracer.start(c.tracer.connContext(nc), ackSpanName, trace.SpanKindConsumer,
+		namespaceAttr.String(namespaceName(header.Namespace)), sidAttr.String(c.ID()))
+
 	// Read the body because Ack can have body as well
-	args, err := c.decoder.DecodeArgs(handler.argTypes)
+	args, rest, err := c.decoder.DecodeArgsWithRest(handler.argTypes)
 	if err != nil {
-		logger.Info("Error decoding the ACK message type", "namespace", header.Namespace, "eventType", handler.argTypes, "err", err.Error())
+		endSpan(span, err)
+		c.log(header.Namespace).Info("decode ack args", "types", handler.argTypes, logger.Err(err))
 		c.onError(header.Namespace, err)
 		return errDecodeArgs
 	}
 
+	if handler.rest {
+		for i := range rest {
+			args = append(args, reflect.ValueOf(&rest[i]).Elem())
+		}
+	}
+
 	// Return value is ignored
 	_, err = handler.Call(args)
+	endSpan(span, err)
 	if err != nil {
-		logger.Info("Error for event type", "namespace", header.Namespace)
+		c.log(header.Namespace).Info("call ack", logger.Err(err))
 		c.onError(header.Namespace, err)
 		return errHandleDispatch
 	}
@@ -57,66 +78,167 @@ func eventPacketHandler(c *conn, event string, header parser.Header) error {
 		return nil
 	}
 
+	if types, ok := streamEventTypes[event]; ok {
+		args, err := c.decoder.DecodeArgs(types)
+		if err != nil {
+			c.onError(header.Namespace, err)
+			c.log(header.Namespace).Info("decode stream args", logger.EventKey, event, logger.Err(err))
+			return errDecodeArgs
+		}
+
+		c.streams.handle(conn, event, args)
+		return nil
+	}
+
 	handler, ok := c.handlers.Get(header.Namespace)
 	if !ok {
 		_ = c.decoder.DiscardLast()
-		logger.Info("missing handler for namespace", "namespace", header.Namespace)
+		c.log(header.Namespace).Info("missing handler for namespace", logger.EventKey, event)
 		return nil
 	}
 
-	args, err := c.decoder.DecodeArgs(handler.getEventTypes(event))
+	// the events are named by the clients, so only the ones handled are
+	// labelled, not to grow the series of the metrics without bound.
+	label := metrics.OtherEvent
+	if handler.hasEvent(event) {
+		label = event
+	}
+	c.metrics.EventReceived(namespaceName(header.Namespace), label)
+
+	types := handler.getEventTypes(event)
+
+	args, rest, err := c.decoder.DecodeArgsValidated(streamTypes(types), handler.getValidate(event))
+	if invalid := (*ValidationError)(nil); errors.As(err, &invalid) {
+		c.log(header.Namespace).Info("invalid event args", logger.EventKey, event, logger.Err(err))
+
+		if header.NeedAck {
+			header.Type = parser.Ack
+			c.write(header, reflect.ValueOf(invalid))
+		} else {
+			conn.Emit(validationErrorEvent, invalid)
+		}
+
+		return nil
+	}
 	if err != nil {
 		c.onError(header.Namespace, err)
-		logger.Info("Error decoding the message type", "namespace", header.Namespace, "event", event, "eventType", handler.getEventTypes(event), "err", err.Error())
+		c.log(header.Namespace).Info("decode event args", logger.EventKey, event, "types", types, logger.Err(err))
 		return errDecodeArgs
 	}
 
-	ret, err := handler.dispatchEvent(conn, event, args...)
+	c.admin.eventReceived(conn, event, args)
+	handler.dispatchAny(conn, event, args, rest)
+
+	// the handler reading streams would block the chunks read by this loop.
+	if hasReader(types) {
+		readers := c.streams.acceptStreams(conn, types, args)
+
+		// the errors are reported by dispatchEvent. The streams not read by
+		// the handler are canceled once it returns.
+		go func() {
+			_ = dispatchEvent(c, conn, handler, event, header, rest, args, true)
+
+			for _, r := range readers {
+				_ = r.Close()
+			}
+		}()
+
+		return nil
+	}
+
+	return dispatchEvent(c, conn, handler, event, header, rest, args, false)
+}
+
+// dispatchEvent calls the handler of the event with args, and writes its ack.
+// The trace context of conn isn't set for the concurrent handlers, which run
+// besides the handlers of the read loop.
+func dispatchEvent(c *conn, conn *namespaceConn, handler *namespaceHandler, event string, header parser.Header, rest []interface{}, args []reflect.Value, concurrent bool) error {
+	ctx, ok := c.tracer.eventContext(rest)
+	if !ok {
+		ctx = c.tracer.connContext(conn)
+	}
+
+	attrs := []attribute.KeyValue{
+		namespaceAttr.String(namespaceName(header.Namespace)),
+		eventAttr.String(event),
+		sidAttr.String(c.ID()),
+	}
+
+	ctx, span := c.tracer.start(ctx, eventSpanName, trace.SpanKindConsumer, attrs...)
+	defer span.End()
+
+	var ret []reflect.Value
+	var err error
+	if concurrent {
+		ret, err = handler.dispatchEvent(conn, event, args...)
+	} else {
+		conn.setTraceContext(ctx)
+		ret, err = handler.dispatchEvent(conn, event, args...)
+		conn.setTraceContext(nil)
+	}
+
 	if err != nil {
+		endSpan(span, err)
 		c.onError(header.Namespace, err)
-		logger.Info("Error for event type", "namespace", header.Namespace, "event", event)
+		c.log(header.Namespace).Info("dispatch event", logger.EventKey, event, logger.Err(err))
 		return errHandleDispatch
 	}
 
 	if len(ret) > 0 || header.NeedAck {
+		_, ackSpan := c.tracer.start(ctx, emitAckSpanName, trace.SpanKindProducer, attrs...)
+
 		header.Type = parser.Ack
 		c.write(header, ret...)
+
+		ackSpan.End()
 	}
 
 	return nil
 }
 
 func connectPacketHandler(c *conn, header parser.Header) error {
-	if err := c.decoder.DiscardLast(); err != nil {
+	var data interface{}
+	if err := c.decoder.DecodeData(&data); err != nil {
 		c.onError(header.Namespace, err)
-		logger.Info("connectPacketHandler DiscardLast", err, "namespace", header.Namespace)
+		c.log(header.Namespace).Info("decode connect auth", logger.Err(err))
 		return nil
 	}
+	// clients may send an empty list instead of the auth object.
+	auth, _ := data.(map[string]interface{})
 
 	handler, ok := c.handlers.Get(header.Namespace)
 	if !ok {
 		c.onError(header.Namespace, errFailedConnectNamespace)
-		logger.Info("connectPacketHandler get namespace handler", "namespace", header.Namespace)
+		c.log(header.Namespace).Info("missing handler for namespace")
 		return errFailedConnectNamespace
 	}
 
-	conn, ok := c.namespaces.Get(header.Namespace)
-	if !ok {
+	conn, connected := c.namespaces.Get(header.Namespace)
+	if !connected {
 		conn = newNamespaceConn(c, header.Namespace, handler.broadcast)
 		c.namespaces.Set(header.Namespace, conn)
+		c.metrics.SocketConnected(namespaceName(header.Namespace))
 		conn.Join(c.Conn.ID())
 	}
+	conn.setAuth(auth)
 
 	_, err := handler.dispatch(conn, header)
 	if err != nil {
-		logger.Info("connectPacketHandler dispatch error", "namespace", header.Namespace)
-		log.Println("dispatch connect packet", err)
+		c.log(header.Namespace).Info("dispatch connect packet", logger.Err(err))
 		c.onError(header.Namespace, err)
 		return errHandleDispatch
 	}
 
 	c.write(header)
 
+	if !connected {
+		c.admin.socketConnected(conn)
+	}
+
+	if handler.afterConnect != nil {
+		handler.afterConnect(conn)
+	}
+
 	return nil
 }
 
@@ -133,9 +255,11 @@ func disconnectPacketHandler(c *conn, header parser.Header) error {
 		return nil
 	}
 
+	c.admin.socketDisconnected(conn, clientDisconnectMsg)
 	conn.LeaveAll()
 
 	c.namespaces.Delete(header.Namespace)
+	c.metrics.SocketDisconnected(namespaceName(header.Namespace))
 
 	handler, ok := c.handlers.Get(header.Namespace)
 	if !ok {
@@ -144,7 +268,7 @@ func disconnectPacketHandler(c *conn, header parser.Header) error {
 
 	_, err = handler.dispatch(conn, header, args...)
 	if err != nil {
-		log.Println("dispatch disconnect packet", err)
+		c.log(header.Namespace).Info("dispatch disconnect packet", logger.Err(err))
 		c.onError(header.Namespace, err)
 		return errHandleDispatch
 	}
@@ -157,15 +281,17 @@ func disconnectPacketHandler(c *conn, header parser.Header) error {
 // ////////////////////
 
 func clientConnectPacketHandler(c *conn, header parser.Header) error {
-	if err := c.decoder.DiscardLast(); err != nil {
-		logger.Info("connectPacketHandler DiscardLast", err, "namespace", header.Namespace)
+	// servers of socket.io v5 answer with the sid of the socket.
+	var data interface{}
+	if err := c.decoder.DecodeData(&data); err != nil {
+		c.log(header.Namespace).Info("decode connect packet", logger.Err(err))
 		c.onError(header.Namespace, err)
 		return nil
 	}
 
 	handler, ok := c.handlers.Get(header.Namespace)
 	if !ok {
-		logger.Info("connectPacketHandler get namespace handler", "namespace", header.Namespace)
+		c.log(header.Namespace).Info("missing handler for namespace")
 		c.onError(header.Namespace, errFailedConnectNamespace)
 		return errFailedConnectNamespace
 	}
@@ -176,11 +302,15 @@ func clientConnectPacketHandler(c *conn, header parser.Header) error {
 		c.namespaces.Set(header.Namespace, conn)
 		conn.Join(c.Conn.ID())
 	}
+	if m, ok := data.(map[string]interface{}); ok {
+		if sid, ok := m["sid"].(string); ok {
+			conn.setSID(sid)
+		}
+	}
 
 	_, err := handler.dispatch(conn, header)
 	if err != nil {
-		logger.Info("connectPacketHandler  dispatch", "namespace", header.Namespace)
-		log.Println("dispatch connect packet", err)
+		c.log(header.Namespace).Info("dispatch connect packet", logger.Err(err))
 		c.onError(header.Namespace, err)
 		return errHandleDispatch
 	}
@@ -188,6 +318,38 @@ func clientConnectPacketHandler(c *conn, header parser.Header) error {
 	return nil
 }
 
+// clientErrorPacketHandler handles the error packet of socket.io v4, or the
+// connect error of socket.io v5 whose data is an object with the message.
+func clientErrorPacketHandler(c *conn, header parser.Header) error {
+	var data interface{}
+	if err := c.decoder.DecodeData(&data); err != nil {
+		c.log(header.Namespace).Info("decode error packet", logger.Err(err))
+		c.onError(header.Namespace, err)
+		return nil
+	}
+
+	msg, _ := data.(string)
+	if m, ok := data.(map[string]interface{}); ok {
+		msg, _ = m["message"].(string)
+	}
+
+	handler, ok := c.handlers.Get(header.Namespace)
+	if !ok {
+		return nil
+	}
+
+	// the namespace isn't connected once its connection is refused.
+	var conn Conn
+	if nc, ok := c.namespaces.Get(header.Namespace); ok {
+		conn = nc
+	}
+
+	// the dispatch of the error packet reports parser.ErrInvalidPacketType.
+	_, _ = handler.dispatch(conn, header, reflect.ValueOf(msg))
+
+	return nil
+}
+
 func clientDisconnectPacketHandler(c *conn, header parser.Header) error {
 	args, err := c.decoder.DecodeArgs(defaultHeaderType)
 	if err != nil {
@@ -212,7 +374,7 @@ func clientDisconnectPacketHandler(c *conn, header parser.Header) error {
 
 	_, err = handler.dispatch(conn, header, args...)
 	if err != nil {
-		log.Println("dispatch disconnect packet", err)
+		c.log(header.Namespace).Info("dispatch disconnect packet", logger.Err(err))
 		c.onError(header.Namespace, err)
 		return errHandleDispatch
 	}
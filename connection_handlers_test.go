@@ -3,12 +3,16 @@ package socketio
 import (
 	"bytes"
 	"io"
+	"log/slog"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/googollee/go-socket.io/engineio"
 	"github.com/googollee/go-socket.io/engineio/session"
+	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/metrics"
 	"github.com/googollee/go-socket.io/parser"
 )
 
@@ -16,6 +20,24 @@ type testStr struct {
 	Result string `json:"result,omitempty"`
 }
 
+type fakeEngineConn struct {
+	engineio.Conn
+
+	id string
+}
+
+func (c *fakeEngineConn) ID() string {
+	return c.id
+}
+
+func (c *fakeEngineConn) Handshake() session.Handshake {
+	return session.Handshake{}
+}
+
+func (c *fakeEngineConn) Transport() string {
+	return "polling"
+}
+
 type fakeReader struct {
 	data  [][]byte
 	index int
@@ -50,6 +72,8 @@ func TestAck(t *testing.T) {
 	namespace := "/test"
 	var id uint64 = 12
 	c := &conn{
+		Conn:       &fakeEngineConn{id: "sid"},
+		tracer:     newTracer(),
 		handlers:   newNamespaceHandlers(),
 		namespaces: newNamespaces(),
 		decoder:    parser.NewDecoder(&fakeReader{data: [][]byte{[]byte("3-/test,12[{\"result\":\"pass\"}]")}}),
@@ -78,3 +102,112 @@ func TestAck(t *testing.T) {
 	must.NoError(err)
 	must.True(called)
 }
+
+func TestConnLog(t *testing.T) {
+	should := assert.New(t)
+
+	buf := bytes.NewBuffer(nil)
+	c := newConn(&fakeEngineConn{id: "sid"}, newNamespaceHandlers(), parser.JSON, nil, newTracer(), slog.New(slog.NewTextHandler(buf, nil)))
+
+	c.log("").Info("message", logger.EventKey, "event")
+	should.Contains(buf.String(), "msg=message sid=sid transport=polling namespace=/ event=event")
+}
+
+// eventMetrics records the events received.
+type eventMetrics struct {
+	metrics.Nop
+
+	received []string
+}
+
+func (m *eventMetrics) EventReceived(namespace, event string) {
+	m.received = append(m.received, namespace+" "+event)
+}
+
+func TestEventMetrics(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	m := &eventMetrics{}
+	c := &conn{
+		Conn:       &fakeEngineConn{id: "sid"},
+		tracer:     newTracer(),
+		metrics:    m,
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		handlers:   newNamespaceHandlers(),
+		namespaces: newNamespaces(),
+		streams:    newStreams(),
+	}
+
+	handler := newNamespaceHandler("/test", newTracer(), nil)
+	handler.OnEvent("msg", func(Conn, string) {})
+	c.handlers.Set("/test", handler)
+	c.namespaces.Set("/test", newNamespaceConn(c, "/test", nil))
+
+	for _, packet := range []string{
+		`2/test,["msg","hi"]`,
+		`2/test,["random-1"]`,
+		`2/other,["msg"]`,
+	} {
+		c.decoder = parser.NewDecoder(&fakeReader{data: [][]byte{[]byte(packet)}})
+
+		var header parser.Header
+		var event string
+		must.NoError(c.decoder.DecodeHeader(&header, &event))
+		must.NoError(eventPacketHandler(c, event, header))
+	}
+
+	// the events without handler, and the namespaces not connected, aren't
+	// labelled.
+	should.Equal([]string{"/test msg", "/test other"}, m.received)
+}
+
+type discardFrameWriter struct{}
+
+func (discardFrameWriter) NextWriter(session.FrameType) (io.WriteCloser, error) {
+	return nopWriteCloser{Writer: io.Discard}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func TestNamespaceConnContext(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	handlers := newNamespaceHandlers()
+	handlers.Set(rootNamespace, newNamespaceHandler(rootNamespace, newTracer(), nil))
+
+	c := newConn(&fakeEngineConn{id: "sid"}, handlers, parser.JSON, metrics.Nop{}, newTracer(), logger.Log)
+	c.encoder = parser.NewEncoder(discardFrameWriter{})
+	must.NoError(c.connect())
+
+	root, ok := c.namespaces.Get(rootNamespace)
+	must.True(ok)
+	chat := newNamespaceConn(c, "/chat", nil)
+	c.namespaces.Set("/chat", chat)
+
+	// each namespace conn has its own context, which isn't the one of the
+	// engine.io conn.
+	should.Nil(root.Context())
+	root.SetContext("root")
+	should.Nil(chat.Context())
+
+	chat.SetContext("chat")
+	should.Equal("root", root.Context())
+	should.Equal("chat", chat.Context())
+
+	// the same goes for the namespace conns of the clients.
+	c = newConn(&fakeEngineConn{id: "sid"}, handlers, parser.JSON, metrics.Nop{}, newTracer(), logger.Log)
+	c.encoder = parser.NewEncoder(discardFrameWriter{})
+	must.NoError(c.connectClient(nil))
+
+	root, ok = c.namespaces.Get(rootNamespace)
+	must.True(ok)
+	should.Nil(root.Context())
+}
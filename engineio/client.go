@@ -1,7 +1,10 @@
 package engineio
 
 import (
+	"errors"
 	"io"
+	"io/ioutil"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
@@ -15,18 +18,37 @@ import (
 	"github.com/googollee/go-socket.io/logger"
 )
 
+// ErrPingTimeout is the error of the reads of a client closed as the server
+// didn't answer its ping with engine.io v3, or didn't ping with engine.io v4.
+var ErrPingTimeout = errors.New("ping timeout")
+
 // Opener is client connection which need receive open message first.
 type Opener interface {
 	Open() (transport.ConnParameters, error)
 }
 
 type client struct {
-	conn      transport.Conn
+	// conn and transport change once the client is upgraded. The writers
+	// hold upgradeLocker for reading until they are closed, so no frame is
+	// written to the old conn after the upgrade.
+	upgradeLocker sync.RWMutex
+	conn          transport.Conn
+	transport     string
+
 	params    transport.ConnParameters
-	transport string
+	version   int
+	handshake session.Handshake
 	context   interface{}
 	close     chan struct{}
 	closeOnce sync.Once
+	// closeErr is the reason of the close, set before close is closed.
+	closeErr error
+
+	// heartbeat gets the pongs with engine.io v3, or the pings with engine.io
+	// v4, read by NextReader.
+	heartbeat chan struct{}
+
+	logger *slog.Logger
 }
 
 func (c *client) SetContext(v interface{}) {
@@ -41,33 +63,79 @@ func (c *client) ID() string {
 	return c.params.SID
 }
 
+func (c *client) Handshake() session.Handshake {
+	return c.handshake
+}
+
 func (c *client) Transport() string {
+	c.upgradeLocker.RLock()
+	defer c.upgradeLocker.RUnlock()
+
 	return c.transport
 }
 
+func (c *client) getConn() transport.Conn {
+	c.upgradeLocker.RLock()
+	defer c.upgradeLocker.RUnlock()
+
+	return c.conn
+}
+
 func (c *client) Close() error {
+	return c.closeWith(nil)
+}
+
+// closeWith closes the client, whose reads then fail with err if it isn't nil.
+func (c *client) closeWith(err error) error {
 	c.closeOnce.Do(func() {
+		c.closeErr = err
 		close(c.close)
 	})
-	return c.conn.Close()
+	return c.getConn().Close()
+}
+
+// closeError gives the reason the client was closed with, if any.
+func (c *client) closeError() error {
+	select {
+	case <-c.close:
+		return c.closeErr
+	default:
+		return nil
+	}
 }
 
 func (c *client) NextReader() (session.FrameType, io.ReadCloser, error) {
 	for {
-		ft, pt, r, err := c.conn.NextReader()
+		conn := c.getConn()
+
+		ft, pt, r, err := conn.NextReader()
 		if err != nil {
+			// the old conn is closed once upgraded.
+			if conn != c.getConn() {
+				continue
+			}
+			if closeErr := c.closeError(); closeErr != nil {
+				return 0, nil, closeErr
+			}
+
 			return 0, nil, err
 		}
 
 		switch pt {
-		case packet.PONG:
-			if err = c.conn.SetReadDeadline(time.Now().Add(c.params.PingInterval + c.params.PingTimeout)); err != nil {
+		case packet.PING:
+			// the server pings with engine.io v4.
+			c.beat()
+			if err = c.pong(r); err != nil {
 				return 0, nil, err
 			}
+			continue
+
+		case packet.PONG:
+			c.beat()
 
 		case packet.CLOSE:
 			if err = c.Close(); err != nil {
-				logger.Error("close client with packet close:", err)
+				c.logger.Error("close client with packet close", logger.Err(err))
 			}
 
 			return 0, nil, io.EOF
@@ -77,38 +145,201 @@ func (c *client) NextReader() (session.FrameType, io.ReadCloser, error) {
 		}
 
 		if err = r.Close(); err != nil {
-			logger.Error("close reader:", err)
+			c.logger.Error("close reader", logger.Err(err))
 		}
 	}
 }
 
 func (c *client) NextWriter(typ session.FrameType) (io.WriteCloser, error) {
-	return c.conn.NextWriter(frame.Type(typ), packet.MESSAGE)
+	return c.nextWriter(frame.Type(typ), packet.MESSAGE)
+}
+
+func (c *client) nextWriter(ft frame.Type, pt packet.Type) (io.WriteCloser, error) {
+	c.upgradeLocker.RLock()
+
+	w, err := c.conn.NextWriter(ft, pt)
+	if err != nil {
+		c.upgradeLocker.RUnlock()
+		return nil, err
+	}
+
+	return &clientWriter{WriteCloser: w, unlock: c.upgradeLocker.RUnlock}, nil
 }
 
 func (c *client) URL() url.URL {
-	return c.conn.URL()
+	return c.getConn().URL()
 }
 
 func (c *client) LocalAddr() net.Addr {
-	return c.conn.LocalAddr()
+	return c.getConn().LocalAddr()
 }
 
 func (c *client) RemoteAddr() net.Addr {
-	return c.conn.RemoteAddr()
+	return c.getConn().RemoteAddr()
 }
 
 func (c *client) RemoteHeader() http.Header {
-	return c.conn.RemoteHeader()
+	return c.getConn().RemoteHeader()
+}
+
+// upgrade probes the transport t with the session of the client, and switches
+// the client to it once the server answered the probe. The client keeps its
+// conn if the probe fails.
+func (c *client) upgrade(t transport.Transport, u url.URL, requestHeader http.Header) {
+	query := u.Query()
+	query.Set("sid", c.params.SID)
+	u.RawQuery = query.Encode()
+
+	conn, err := t.Dial(&u, requestHeader)
+	if err != nil {
+		c.logger.Error("dial upgrade transport", logger.TransportKey, t.Name(), logger.Err(err))
+
+		return
+	}
+
+	// Pause the old conn by waiting for its writers and their frames to be
+	// received, as the server pauses it once probed, then switch to the
+	// upgraded conn.
+	c.upgradeLocker.Lock()
+
+	select {
+	case <-c.close:
+		err = io.EOF
+	default:
+		if f, ok := c.conn.(flusher); ok {
+			err = f.Flush()
+		}
+		if err == nil {
+			err = probe(conn, c.params.PingTimeout)
+		}
+	}
+	if err == nil {
+		err = writePacket(conn, packet.UPGRADE, "")
+	}
+	if err != nil {
+		c.logger.Error("probe upgrade transport", logger.TransportKey, t.Name(), logger.Err(err))
+
+		c.upgradeLocker.Unlock()
+
+		if closeErr := conn.Close(); closeErr != nil {
+			c.logger.Error("close upgrade connect", logger.Err(closeErr))
+		}
+
+		return
+	}
+
+	old := c.conn
+	c.conn = conn
+	c.transport = t.Name()
+	c.upgradeLocker.Unlock()
+
+	if err = old.Close(); err != nil {
+		c.logger.Error("close old connect", logger.Err(err))
+	}
 }
 
+// flusher is a conn whose frames are sent after its writers are closed, like
+// the polling one.
+type flusher interface {
+	// Flush waits for the frames written to be sent.
+	Flush() error
+}
+
+// probe sends the ping probe with conn, and waits for the pong probe until
+// timeout.
+func probe(conn transport.Conn, timeout time.Duration) error {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	if err := writePacket(conn, packet.PING, "probe"); err != nil {
+		return err
+	}
+
+	_, pt, r, err := conn.NextReader()
+	if err != nil {
+		return err
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if closeErr := r.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if pt != packet.PONG || string(b) != "probe" {
+		return errors.New("invalid probe")
+	}
+
+	return conn.SetReadDeadline(time.Time{})
+}
+
+func writePacket(conn transport.Conn, pt packet.Type, data string) error {
+	w, err := conn.NextWriter(frame.String, pt)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.WriteString(w, data); err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+// beat notifies serve of a heartbeat of the server.
+func (c *client) beat() {
+	select {
+	case c.heartbeat <- struct{}{}:
+	default:
+	}
+}
+
+// pong answers the ping of the server read from r with the same data.
+func (c *client) pong(r io.ReadCloser) error {
+	b, err := ioutil.ReadAll(r)
+	if closeErr := r.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	w, err := c.nextWriter(frame.String, packet.PONG)
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write(b); err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+// serve monitors the heartbeat of the server, and closes the client with
+// ErrPingTimeout once the server is silent.
 func (c *client) serve() {
 	defer func() {
-		if closeErr := c.conn.Close(); closeErr != nil {
-			logger.Error("close connect:", closeErr)
+		if closeErr := c.getConn().Close(); closeErr != nil {
+			c.logger.Error("close connect", logger.Err(closeErr))
 		}
 	}()
 
+	if c.version == 3 {
+		c.ping()
+	} else {
+		c.expectPings()
+	}
+}
+
+// ping pings the server every PingInterval, which must answer within
+// PingTimeout, as engine.io v3.
+func (c *client) ping() {
 	for {
 		select {
 		case <-c.close:
@@ -116,21 +347,80 @@ func (c *client) serve() {
 		case <-time.After(c.params.PingInterval):
 		}
 
-		w, err := c.conn.NextWriter(frame.String, packet.PING)
+		w, err := c.nextWriter(frame.String, packet.PING)
 		if err != nil {
-			logger.Error("get next writer with string frame and packet ping:", err)
+			c.logger.Error("next writer of ping", logger.Err(err))
 
 			return
 		}
 
 		if err = w.Close(); err != nil {
-			logger.Error("close writer:", err)
+			c.logger.Error("close writer", logger.Err(err))
 
 			return
 		}
 
-		if err = c.conn.SetWriteDeadline(time.Now().Add(c.params.PingInterval + c.params.PingTimeout)); err != nil {
-			logger.Error("set writer deadline:", err)
+		if err = c.getConn().SetWriteDeadline(time.Now().Add(c.params.PingInterval + c.params.PingTimeout)); err != nil {
+			c.logger.Error("set writer deadline", logger.Err(err))
 		}
+
+		select {
+		case <-c.close:
+			return
+		case <-c.heartbeat:
+		case <-time.After(c.params.PingTimeout):
+			c.timeout()
+			return
+		}
+	}
+}
+
+// expectPings waits for the ping of the server every PingInterval, within
+// PingTimeout, as engine.io v4.
+func (c *client) expectPings() {
+	timer := time.NewTimer(c.params.PingInterval + c.params.PingTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-c.close:
+			return
+		case <-c.heartbeat:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(c.params.PingInterval + c.params.PingTimeout)
+		case <-timer.C:
+			c.timeout()
+			return
+		}
+	}
+}
+
+// timeout closes the client, whose reads then fail with ErrPingTimeout.
+func (c *client) timeout() {
+	c.logger.Info("close client", logger.Err(ErrPingTimeout))
+
+	_ = c.closeWith(ErrPingTimeout)
+}
+
+// clientWriter releases the upgrade lock of the client once closed.
+type clientWriter struct {
+	io.WriteCloser
+
+	unlockOnce sync.Once
+	unlock     func()
+}
+
+func (w *clientWriter) Close() error {
+	defer w.unlockOnce.Do(w.unlock)
+
+	return w.WriteCloser.Close()
+}
+
+// SetCompress forwards to the writer of the transport.
+func (w *clientWriter) SetCompress(compress bool) {
+	if c, ok := w.WriteCloser.(transport.Compressor); ok {
+		c.SetCompress(compress)
 	}
 }
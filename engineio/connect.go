@@ -19,6 +19,8 @@ type Conn interface {
 	LocalAddr() net.Addr
 	RemoteAddr() net.Addr
 	RemoteHeader() http.Header
+	Handshake() session.Handshake
+	Transport() string
 	SetContext(v interface{})
 	Context() interface{}
 }
@@ -0,0 +1,103 @@
+package engineio
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/googollee/go-socket.io/engineio/transport"
+)
+
+const corsAllowedMethods = "GET, POST, OPTIONS"
+
+// CORSOptions are the cross-origin requests allowed by a server. They apply to
+// all transports: the requests of the origins not allowed are refused, which
+// are the upgrades to websocket too, and the preflights are answered by the
+// server.
+type CORSOptions struct {
+	// AllowedOrigins are the origins allowed, like "https://example.com", or
+	// "*" for any origin.
+	AllowedOrigins []string
+	// AllowOriginFunc tells if the origin of r is allowed, instead of
+	// AllowedOrigins.
+	AllowOriginFunc func(r *http.Request, origin string) bool
+
+	// AllowCredentials allows the requests with credentials, like cookies.
+	AllowCredentials bool
+	// AllowedHeaders are the headers allowed in requests, Content-Type by
+	// default.
+	AllowedHeaders []string
+	// MaxAge is how long the preflight responses are cached. They aren't if
+	// it's 0.
+	MaxAge time.Duration
+}
+
+func (c *CORSOptions) allowed(r *http.Request, origin string) bool {
+	if c.AllowOriginFunc != nil {
+		return c.AllowOriginFunc(r, origin)
+	}
+
+	for _, o := range c.AllowedOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORSOptions) allowedHeaders() string {
+	if len(c.AllowedHeaders) != 0 {
+		return strings.Join(c.AllowedHeaders, ", ")
+	}
+	return "Content-Type"
+}
+
+// serve sets the CORS headers of the response to r, and returns r with its
+// origin allowed. It tells if the request is to be served, which it isn't if
+// its origin is refused, or if it's a preflight, which is answered.
+func (c *CORSOptions) serve(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// the requests without origin aren't cross-origin.
+		return transport.AllowOrigin(r), true
+	}
+
+	if !c.allowed(r, origin) {
+		http.Error(w, "origin not allowed: "+origin, http.StatusForbidden)
+		return r, false
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	if !c.AllowCredentials && c.AllowOriginFunc == nil && c.any() {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if r.Method == http.MethodOptions {
+		header.Set("Access-Control-Allow-Methods", corsAllowedMethods)
+		header.Set("Access-Control-Allow-Headers", c.allowedHeaders())
+		if c.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return r, false
+	}
+
+	return transport.AllowOrigin(r), true
+}
+
+// any tells if any origin is allowed.
+func (c *CORSOptions) any() bool {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package engineio
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/engineio/transport/websocket"
+)
+
+func TestCORSOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		cors    CORSOptions
+		method  string
+		origin  string
+		served  bool
+		status  int
+		headers map[string]string
+	}{
+		{
+			name:   "no origin",
+			cors:   CORSOptions{AllowedOrigins: []string{"https://a.com"}},
+			method: http.MethodGet,
+			served: true,
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:   "listed origin",
+			cors:   CORSOptions{AllowedOrigins: []string{"https://a.com"}},
+			method: http.MethodGet,
+			origin: "https://A.com",
+			served: true,
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":      "https://A.com",
+				"Access-Control-Allow-Credentials": "",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:   "refused origin",
+			cors:   CORSOptions{AllowedOrigins: []string{"https://a.com"}},
+			method: http.MethodPost,
+			origin: "https://b.com",
+			status: http.StatusForbidden,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:   "wildcard",
+			cors:   CORSOptions{AllowedOrigins: []string{"*"}},
+			method: http.MethodGet,
+			origin: "https://b.com",
+			served: true,
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin": "*",
+			},
+		},
+		{
+			name:   "wildcard with credentials",
+			cors:   CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			method: http.MethodGet,
+			origin: "https://b.com",
+			served: true,
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":      "https://b.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			name: "func",
+			cors: CORSOptions{AllowOriginFunc: func(_ *http.Request, origin string) bool {
+				return strings.HasSuffix(origin, ".a.com")
+			}},
+			method: http.MethodGet,
+			origin: "https://sub.a.com",
+			served: true,
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin": "https://sub.a.com",
+			},
+		},
+		{
+			name: "preflight",
+			cors: CORSOptions{
+				AllowedOrigins: []string{"https://a.com"},
+				AllowedHeaders: []string{"Content-Type", "Authorization"},
+				MaxAge:         10 * time.Minute,
+			},
+			method: http.MethodOptions,
+			origin: "https://a.com",
+			status: http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  "https://a.com",
+				"Access-Control-Allow-Methods": "GET, POST, OPTIONS",
+				"Access-Control-Allow-Headers": "Content-Type, Authorization",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:   "preflight defaults",
+			cors:   CORSOptions{AllowedOrigins: []string{"https://a.com"}},
+			method: http.MethodOptions,
+			origin: "https://a.com",
+			status: http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Headers": "Content-Type",
+				"Access-Control-Max-Age":       "",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			should := assert.New(t)
+
+			r := httptest.NewRequest(test.method, "/engine.io/", nil)
+			if test.origin != "" {
+				r.Header.Set("Origin", test.origin)
+			}
+			w := httptest.NewRecorder()
+
+			sr, served := test.cors.serve(w, r)
+			should.Equal(test.served, served)
+			should.Equal(test.served, transport.OriginAllowed(sr))
+			should.Equal(test.status, w.Code)
+			for k, v := range test.headers {
+				should.Equal(v, w.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestEngineCORS(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	svr := NewServer(&Options{
+		CORS: &CORSOptions{
+			AllowedOrigins:   []string{"https://a.com"},
+			AllowCredentials: true,
+		},
+	})
+
+	httpSvr := httptest.NewServer(svr)
+	defer httpSvr.Close()
+
+	// the sessions of the polling and websocket requests allowed are accepted
+	// before the server is closed.
+	accepted := make(chan struct{})
+	go func() {
+		defer close(accepted)
+		for i := 0; i < 2; i++ {
+			conn, err := svr.Accept()
+			if !assert.NoError(t, err) {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	defer func() {
+		<-accepted
+		must.NoError(svr.Close())
+	}()
+
+	get := func(origin string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, httpSvr.URL+"/?EIO=3&transport=polling", nil)
+		must.NoError(err)
+		req.Header.Set("Origin", origin)
+
+		resp, err := http.DefaultClient.Do(req)
+		must.NoError(err)
+		must.NoError(resp.Body.Close())
+
+		return resp
+	}
+
+	// the polling transport doesn't set its own headers.
+	resp := get("https://a.com")
+	should.Equal(http.StatusOK, resp.StatusCode)
+	should.Equal("https://a.com", resp.Header.Get("Access-Control-Allow-Origin"))
+	should.Equal("true", resp.Header.Get("Access-Control-Allow-Credentials"))
+
+	resp = get("https://b.com")
+	should.Equal(http.StatusForbidden, resp.StatusCode)
+
+	// the websocket transport allows the origins of the server, which it
+	// refuses by default as they aren't the origin of the server.
+	u, err := url.Parse(strings.Replace(httpSvr.URL, "http", "ws", 1) + "/?EIO=3")
+	must.NoError(err)
+	header := http.Header{}
+	header.Set("Origin", "https://a.com")
+
+	dialer := websocket.Transport{}
+	conn, err := dialer.Dial(u, header)
+	must.NoError(err)
+	must.NoError(conn.Close())
+
+	header.Set("Origin", "https://b.com")
+	_, err = dialer.Dial(u, header)
+	should.Error(err)
+	if de, ok := err.(websocket.DialError); should.True(ok) {
+		should.Equal(http.StatusForbidden, de.Response.StatusCode)
+	}
+}
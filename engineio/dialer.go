@@ -3,46 +3,80 @@ package engineio
 import (
 	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/googollee/go-socket.io/engineio/packet"
+	"github.com/googollee/go-socket.io/engineio/session"
 	"github.com/googollee/go-socket.io/engineio/transport"
 	"github.com/googollee/go-socket.io/logger"
 )
 
 // Dialer is dialer configure.
 type Dialer struct {
+	// Transports are tried in order until one connects. The connection is
+	// then upgraded to the first of the next transports which the server
+	// allows, like polling then websocket.
 	Transports []transport.Transport
+
+	// Version is the engine.io protocol version, 3 or 4, which is 4 by
+	// default. The servers of this module speak version 3 only. Servers send
+	// the pings of version 4, and clients the pings of version 3.
+	Version int
+
+	// Logger logs the connections dialed. logger.Log is used by default.
+	Logger *slog.Logger
+}
+
+func (d *Dialer) logger() *slog.Logger {
+	if d.Logger != nil {
+		return d.Logger
+	}
+	return logger.Log
+}
+
+func (d *Dialer) version() int {
+	if d.Version == 3 {
+		return 3
+	}
+	return 4
 }
 
 // Dial returns a connection which dials to url with requestHeader.
 func (d *Dialer) Dial(urlStr string, requestHeader http.Header) (Conn, error) {
+	l := d.logger()
+
 	u, err := url.Parse(urlStr)
 	if err != nil {
-		logger.Error("parse url str:", err)
+		l.Error("parse url", logger.Err(err))
 
 		return nil, err
 	}
 
 	query := u.Query()
-	query.Set("EIO", "3")
+	query.Set("EIO", strconv.Itoa(d.version()))
 	u.RawQuery = query.Encode()
 
 	var conn transport.Conn
 
-	for i := len(d.Transports) - 1; i >= 0; i-- {
+	for i := range d.Transports {
 		if conn != nil {
 			if closeErr := conn.Close(); closeErr != nil {
-				logger.Error("close connect:", closeErr)
+				l.Error("close connect", logger.Err(closeErr))
 			}
 		}
 
 		t := d.Transports[i]
 
-		conn, err = t.Dial(u, requestHeader)
+		// the transports set their scheme and query in the url.
+		tu := *u
+
+		conn, err = t.Dial(&tu, requestHeader)
 		if err != nil {
-			logger.Error("transport dial:", err)
+			l.Error("transport dial", logger.TransportKey, t.Name(), logger.Err(err))
 
 			continue
 		}
@@ -51,7 +85,7 @@ func (d *Dialer) Dial(urlStr string, requestHeader http.Header) (Conn, error) {
 		if p, ok := conn.(Opener); ok {
 			params, err = p.Open()
 			if err != nil {
-				logger.Error("open transport connect:", err)
+				l.Error("open transport connect", logger.TransportKey, t.Name(), logger.Err(err))
 
 				continue
 			}
@@ -67,7 +101,7 @@ func (d *Dialer) Dial(urlStr string, requestHeader http.Header) (Conn, error) {
 			func() {
 				defer func() {
 					if closeErr := r.Close(); closeErr != nil {
-						logger.Error("close connect reader:", closeErr)
+						l.Error("close connect reader", logger.Err(closeErr))
 					}
 				}()
 
@@ -84,7 +118,7 @@ func (d *Dialer) Dial(urlStr string, requestHeader http.Header) (Conn, error) {
 			}()
 		}
 		if err != nil {
-			logger.Error("transport dialer:", err)
+			l.Error("transport dialer", logger.TransportKey, t.Name(), logger.Err(err))
 
 			continue
 		}
@@ -93,13 +127,43 @@ func (d *Dialer) Dial(urlStr string, requestHeader http.Header) (Conn, error) {
 			conn:      conn,
 			params:    params,
 			transport: t.Name(),
+			version:   d.version(),
+			logger:    l.With(logger.SIDKey, params.SID),
+			handshake: session.Handshake{
+				Time:      time.Now(),
+				URL:       tu,
+				Query:     tu.Query(),
+				Header:    requestHeader.Clone(),
+				Address:   u.Host,
+				Secure:    u.Scheme == "https" || u.Scheme == "wss",
+				Transport: t.Name(),
+			},
 			close:     make(chan struct{}),
+			heartbeat: make(chan struct{}, 1),
 		}
 
 		go ret.serve()
 
+		if up := d.upgrade(i, params); up != nil {
+			go ret.upgrade(up, *u, requestHeader)
+		}
+
 		return ret, nil
 	}
 
 	return nil, err
 }
+
+// upgrade gives the first transport after the i-th which is in the upgrades of
+// params, or nil.
+func (d *Dialer) upgrade(i int, params transport.ConnParameters) transport.Transport {
+	for _, t := range d.Transports[i+1:] {
+		for _, name := range params.Upgrades {
+			if t.Name() == name {
+				return t
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package engineio
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gorilla "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/googollee/go-socket.io/engineio/session"
+	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/engineio/transport/polling"
+	"github.com/googollee/go-socket.io/engineio/transport/websocket"
+)
+
+const v4Open = `0{"sid":"v4","upgrades":[],"pingInterval":25000,"pingTimeout":5000,"maxPayload":1000000}`
+
+func TestDialV4Polling(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	done := make(chan struct{})
+	posts := make(chan string, 4)
+	var gets int32
+
+	// the server of engine.io v4 pings, and separates the packets.
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		should.Equal("4", r.URL.Query().Get("EIO"))
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+		if r.Method == http.MethodPost {
+			b, err := ioutil.ReadAll(r.Body)
+			must.NoError(err)
+			posts <- string(b)
+			_, _ = w.Write([]byte("ok"))
+			return
+		}
+
+		switch atomic.AddInt32(&gets, 1) {
+		case 1:
+			_, _ = w.Write([]byte(v4Open))
+		case 2:
+			_, _ = w.Write([]byte("2\x1e4hello\x1ebAQI="))
+		default:
+			<-done
+			_, _ = w.Write([]byte("6"))
+		}
+	}))
+	defer hs.Close()
+	defer close(done)
+
+	dialer := Dialer{
+		Transports: []transport.Transport{polling.Default},
+	}
+
+	conn, err := dialer.Dial(hs.URL, nil)
+	must.NoError(err)
+	defer conn.Close()
+	should.Equal("v4", conn.ID())
+
+	ft, r, err := conn.NextReader()
+	must.NoError(err)
+	should.Equal(session.TEXT, ft)
+	b, err := ioutil.ReadAll(r)
+	must.NoError(err)
+	must.NoError(r.Close())
+	should.Equal("hello", string(b))
+
+	should.Equal("3", <-posts)
+
+	ft, r, err = conn.NextReader()
+	must.NoError(err)
+	should.Equal(session.BINARY, ft)
+	b, err = ioutil.ReadAll(r)
+	must.NoError(err)
+	must.NoError(r.Close())
+	should.Equal([]byte{1, 2}, b)
+
+	w, err := conn.NextWriter(session.BINARY)
+	must.NoError(err)
+	_, err = w.Write([]byte{3, 4})
+	must.NoError(err)
+	must.NoError(w.Close())
+
+	should.Equal("bAwQ=", <-posts)
+}
+
+func TestDialV4Websocket(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	upgrader := gorilla.Upgrader{}
+	received := make(chan []byte, 2)
+
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		should.Equal("4", r.URL.Query().Get("EIO"))
+
+		c, err := upgrader.Upgrade(w, r, nil)
+		must.NoError(err)
+		defer c.Close()
+
+		must.NoError(c.WriteMessage(gorilla.TextMessage, []byte(v4Open)))
+		must.NoError(c.WriteMessage(gorilla.TextMessage, []byte("2")))
+		must.NoError(c.WriteMessage(gorilla.BinaryMessage, []byte{1, 2}))
+
+		for i := 0; i < 2; i++ {
+			_, b, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- b
+		}
+	}))
+	defer hs.Close()
+
+	dialer := Dialer{
+		Transports: []transport.Transport{websocket.Default},
+	}
+
+	conn, err := dialer.Dial(hs.URL, nil)
+	must.NoError(err)
+	defer conn.Close()
+
+	ft, r, err := conn.NextReader()
+	must.NoError(err)
+	should.Equal(session.BINARY, ft)
+	b, err := ioutil.ReadAll(r)
+	must.NoError(err)
+	must.NoError(r.Close())
+	should.Equal([]byte{1, 2}, b)
+
+	w, err := conn.NextWriter(session.BINARY)
+	must.NoError(err)
+	_, err = w.Write([]byte{3, 4})
+	must.NoError(err)
+	must.NoError(w.Close())
+
+	for _, want := range [][]byte{[]byte("3"), {3, 4}} {
+		select {
+		case b := <-received:
+			should.Equal(want, b)
+		case <-time.After(5 * time.Second):
+			must.FailNow("no message from the client")
+		}
+	}
+}
+
+func TestDialPingTimeout(t *testing.T) {
+	for _, version := range []int{3, 4} {
+		t.Run(fmt.Sprintf("EIO%d", version), func(t *testing.T) {
+			should := assert.New(t)
+			must := require.New(t)
+
+			upgrader := gorilla.Upgrader{}
+
+			// the server neither pings nor answers the pings.
+			hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c, err := upgrader.Upgrade(w, r, nil)
+				must.NoError(err)
+				defer c.Close()
+
+				open := `0{"sid":"silent","upgrades":[],"pingInterval":50,"pingTimeout":50}`
+				must.NoError(c.WriteMessage(gorilla.TextMessage, []byte(open)))
+
+				for {
+					if _, _, err := c.ReadMessage(); err != nil {
+						return
+					}
+				}
+			}))
+			defer hs.Close()
+
+			var buf syncBuffer
+			dialer := Dialer{
+				Transports: []transport.Transport{websocket.Default},
+				Version:    version,
+				Logger:     slog.New(slog.NewTextHandler(&buf, nil)),
+			}
+
+			conn, err := dialer.Dial(hs.URL, nil)
+			must.NoError(err)
+			defer conn.Close()
+
+			start := time.Now()
+			_, _, err = conn.NextReader()
+			should.Equal(ErrPingTimeout, err)
+			should.Less(time.Since(start), time.Second)
+
+			// the timeout is logged once, and isn't an error.
+			logs := buf.String()
+			should.Equal(1, strings.Count(logs, "ping timeout"), logs)
+			should.Contains(logs, "level=INFO")
+			should.NotContains(logs, "level=ERROR")
+		})
+	}
+}
+
+func TestDialLogger(t *testing.T) {
+	should := assert.New(t)
+
+	hs := httptest.NewServer(http.NotFoundHandler())
+	defer hs.Close()
+
+	var buf bytes.Buffer
+	dialer := Dialer{
+		Transports: []transport.Transport{websocket.Default},
+		Logger:     slog.New(slog.NewTextHandler(&buf, nil)),
+	}
+
+	_, err := dialer.Dial(hs.URL, nil)
+	should.Error(err)
+	should.Contains(buf.String(), `msg="transport dial" transport=websocket`)
+}
+
+// syncBuffer is a buffer for the logs written by several goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
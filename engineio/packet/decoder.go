@@ -13,6 +13,9 @@ type FrameReader interface {
 
 type Decoder struct {
 	r FrameReader
+
+	// v4 tells if binary frames are messages without the packet type.
+	v4 bool
 }
 
 func NewDecoder(r FrameReader) *Decoder {
@@ -21,11 +24,23 @@ func NewDecoder(r FrameReader) *Decoder {
 	}
 }
 
+// NewDecoderV4 returns the decoder of engine.io v4, whose binary frames are
+// messages without the packet type byte.
+func NewDecoderV4(r FrameReader) *Decoder {
+	return &Decoder{
+		r:  r,
+		v4: true,
+	}
+}
+
 func (e *Decoder) NextReader() (frame.Type, Type, io.ReadCloser, error) {
 	ft, r, err := e.r.NextReader()
 	if err != nil {
 		return 0, 0, nil, err
 	}
+	if e.v4 && ft == frame.Binary {
+		return ft, MESSAGE, r, nil
+	}
 	var b [1]byte
 	if _, err := io.ReadFull(r, b[:]); err != nil {
 		_ = r.Close()
@@ -116,3 +116,33 @@ func BenchmarkDecoder(b *testing.B) {
 		}
 	}
 }
+
+func TestDecoderV4(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	decoder := NewDecoderV4(NewFakeConnReader([]Frame{
+		{FType: frame.String, Data: []byte("2")},
+		{FType: frame.Binary, Data: []byte{1, 2}},
+	}))
+
+	var output []Packet
+	for {
+		ft, pt, fr, err := decoder.NextReader()
+		if err != nil {
+			should.Equal(io.EOF, err)
+			break
+		}
+
+		b, err := ioutil.ReadAll(fr)
+		must.NoError(err)
+		must.NoError(fr.Close())
+
+		output = append(output, Packet{FType: ft, PType: pt, Data: b})
+	}
+
+	should.Equal([]Packet{
+		{FType: frame.String, PType: PING, Data: []byte{}},
+		{FType: frame.Binary, PType: MESSAGE, Data: []byte{1, 2}},
+	}, output)
+}
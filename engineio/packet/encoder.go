@@ -14,6 +14,9 @@ type FrameWriter interface {
 
 type Encoder struct {
 	w FrameWriter
+
+	// v4 tells if binary frames are messages without the packet type.
+	v4 bool
 }
 
 func NewEncoder(w FrameWriter) *Encoder {
@@ -22,12 +25,30 @@ func NewEncoder(w FrameWriter) *Encoder {
 	}
 }
 
+// NewEncoderV4 returns the encoder of engine.io v4, whose binary frames are
+// messages without the packet type byte.
+func NewEncoderV4(w FrameWriter) *Encoder {
+	return &Encoder{
+		w:  w,
+		v4: true,
+	}
+}
+
 func (e *Encoder) NextWriter(ft frame.Type, pt Type) (io.WriteCloser, error) {
 	w, err := e.w.NextWriter(ft)
 	if err != nil {
 		return nil, err
 	}
 
+	if e.v4 && ft == frame.Binary {
+		if pt != MESSAGE {
+			_ = w.Close()
+			return nil, errBinaryNotMessage
+		}
+
+		return w, nil
+	}
+
 	var b [1]byte
 	if ft == frame.String {
 		b[0] = pt.StringByte()
@@ -53,3 +53,29 @@ func BenchmarkEncoder(b *testing.B) {
 		}
 	}
 }
+
+func TestEncoderV4(t *testing.T) {
+	at := assert.New(t)
+
+	w := NewFakeConnWriter()
+	encoder := NewEncoderV4(w)
+
+	for _, p := range []Packet{
+		{FType: frame.String, PType: PING, Data: []byte{}},
+		{FType: frame.Binary, PType: MESSAGE, Data: []byte{1, 2}},
+	} {
+		fw, err := encoder.NextWriter(p.FType, p.PType)
+		at.Nil(err)
+		_, err = fw.Write(p.Data)
+		at.Nil(err)
+		at.Nil(fw.Close())
+	}
+
+	at.Equal([]Frame{
+		{FType: frame.String, Data: []byte("2")},
+		{FType: frame.Binary, Data: []byte{1, 2}},
+	}, w.Frames)
+
+	_, err := encoder.NextWriter(frame.Binary, PING)
+	at.Equal(errBinaryNotMessage, err)
+}
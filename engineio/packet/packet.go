@@ -1,6 +1,12 @@
 package packet
 
-import "github.com/googollee/go-socket.io/engineio/frame"
+import (
+	"errors"
+
+	"github.com/googollee/go-socket.io/engineio/frame"
+)
+
+var errBinaryNotMessage = errors.New("binary frame of engine.io v4 must be a message")
 
 // Type is the type of packet
 type Type int
@@ -25,7 +25,8 @@ var tests = []struct {
 			{frame.String, packet.MESSAGE, []byte("hello 你好")},
 		},
 	},
-	{true, []byte{0x01, 0x09, 0xff, 0x04, 'h', 'e', 'l', 'l', 'o', ' ', 0xe4, 0xbd, 0xa0, 0xe5, 0xa5, 0xbd}, []Packet{
+	// the length of binary frames is in bytes
+	{true, []byte{0x01, 0x01, 0x03, 0xff, 0x04, 'h', 'e', 'l', 'l', 'o', ' ', 0xe4, 0xbd, 0xa0, 0xe5, 0xa5, 0xbd}, []Packet{
 		{frame.Binary, packet.MESSAGE, []byte("hello 你好")},
 	},
 	},
@@ -29,6 +29,11 @@ type decoder struct {
 	ft            frame.Type
 	pt            packet.Type
 	supportBinary bool
+
+	// v4 tells if the packets are decoded as engine.io v4, till the record
+	// separator.
+	v4        bool
+	sepReader separatedReader
 }
 
 func (d *decoder) NextReader() (frame.Type, packet.Type, io.ReadCloser, error) {
@@ -53,7 +58,15 @@ func (d *decoder) Read(p []byte) (int, error) {
 	if d.b64Reader != nil {
 		return d.b64Reader.Read(p)
 	}
+	if d.v4 {
+		return d.sepReader.Read(p)
+	}
 	dd, err := d.limitReader.Read(p)
+	if d.ft != frame.String {
+		// The length of binary frames is in bytes.
+		return dd, err
+	}
+
 	unicodeCount := 0
 	for i := range p[:dd] {
 		b := p[i]
@@ -92,6 +105,10 @@ func (d *decoder) Close() error {
 }
 
 func (d *decoder) setNextReader(r byteReader, supportBinary bool) error {
+	if d.v4 {
+		return d.setNextV4Reader(r)
+	}
+
 	var read func(byteReader) (frame.Type, packet.Type, int64, error)
 	if supportBinary {
 		read = d.binaryRead
@@ -118,6 +135,30 @@ func (d *decoder) setNextReader(r byteReader, supportBinary bool) error {
 	return nil
 }
 
+// setNextV4Reader reads the type of the next packet of engine.io v4, whose
+// data ends with the record separator or the payload.
+func (d *decoder) setNextV4Reader(r byteReader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	d.ft = frame.String
+	d.pt = packet.ByteToPacketType(b, frame.String)
+	if b == 'b' {
+		d.ft = frame.Binary
+		d.pt = packet.MESSAGE
+	}
+
+	d.rawReader = r
+	d.sepReader = separatedReader{r: r}
+	d.b64Reader = nil
+	if d.ft == frame.Binary {
+		d.b64Reader = base64.NewDecoder(base64.StdEncoding, &d.sepReader)
+	}
+	return nil
+}
+
 func (d *decoder) sendError(err error) error {
 	if e := d.feeder.putReader(err); e != nil {
 		return e
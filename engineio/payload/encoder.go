@@ -18,6 +18,8 @@ type writerFeeder interface {
 type encoder struct {
 	supportBinary bool
 	feeder        writerFeeder
+	// v4 tells if the packets are encoded as engine.io v4, without length.
+	v4 bool
 
 	ft         frame.Type
 	pt         packet.Type
@@ -28,6 +30,9 @@ type encoder struct {
 }
 
 func (e *encoder) NOOP() []byte {
+	if e.v4 {
+		return []byte("6")
+	}
 	if e.supportBinary {
 		return []byte{0x00, 0x01, 0xff, '6'}
 	}
@@ -69,7 +74,9 @@ func (e *encoder) Close() error {
 	}
 
 	var writeHeader func() error
-	if e.supportBinary {
+	if e.v4 {
+		writeHeader = e.writeV4Header
+	} else if e.supportBinary {
 		writeHeader = e.writeBinaryHeader
 	} else {
 		if e.ft == frame.Binary {
@@ -114,6 +121,18 @@ func (e *encoder) writeB64Header() error {
 	return err
 }
 
+// writeV4Header writes the packet type, or 'b' for binary messages, as the
+// packets of engine.io v4 are separated instead of prefixed by their length.
+func (e *encoder) writeV4Header() error {
+	if e.ft == frame.Binary {
+		if e.pt != packet.MESSAGE {
+			return errBinaryNotMessage
+		}
+		return e.header.WriteByte('b')
+	}
+	return e.header.WriteByte(e.pt.StringByte())
+}
+
 func (e *encoder) calcCodeUnitLength() int64 {
 	var l int64 = 1
 	var codeUnitSize int64
@@ -144,6 +163,8 @@ func (e *encoder) writeBinaryHeader() error {
 	l := int64(e.calcCodeUnitLength()) // length for packet type
 	b := e.pt.StringByte()
 	if e.ft == frame.Binary {
+		// The length of binary frames is in bytes.
+		l = int64(e.frameCache.Len() + 1)
 		b = e.pt.BinaryByte()
 	}
 	err := e.header.WriteByte(e.ft.Byte())
@@ -273,3 +273,40 @@ func BenchmarkBinaryEncoder(b *testing.B) {
 		}
 	}
 }
+
+func TestEncoderBinaryLength(t *testing.T) {
+	assert := assert.New(t)
+	must := require.New(t)
+
+	// the bytes of binary frames, like msgpack, aren't UTF-8 and are counted
+	// as they are.
+	data := []byte{0x82, 0xa4, 't', 'y', 'p', 'e', 0x02, 0xc0, 0xff, 0xfe}
+
+	buf := bytes.NewBuffer(nil)
+	e := encoder{
+		supportBinary: true,
+		feeder:        &fakeWriterFeeder{w: buf},
+	}
+
+	fw, err := e.NextWriter(frame.Binary, packet.MESSAGE)
+	must.Nil(err)
+	_, err = fw.Write(data)
+	must.Nil(err)
+	must.Nil(fw.Close())
+
+	assert.Equal(append([]byte{0x01, 0x01, 0x01, 0xff, 0x04}, data...), buf.Bytes())
+
+	d := decoder{
+		feeder: &fakeReaderFeeder{data: buf.Bytes(), supportBinary: true},
+	}
+
+	ft, pt, fr, err := d.NextReader()
+	must.Nil(err)
+	b, err := ioutil.ReadAll(fr)
+	must.Nil(err)
+	must.Nil(fr.Close())
+
+	assert.Equal(frame.Binary, ft)
+	assert.Equal(packet.MESSAGE, pt)
+	assert.Equal(data, b)
+}
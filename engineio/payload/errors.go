@@ -55,3 +55,5 @@ var errTimeout = errors.New("timeout")
 var errInvalidPayload = errors.New("invalid payload")
 
 var errOverlap = errors.New("overlap")
+
+var errBinaryNotMessage = errors.New("binary packet of engine.io v4 must be a message")
@@ -55,6 +55,15 @@ func New(supportBinary bool) *Payload {
 	return ret
 }
 
+// NewV4 returns a new payload of engine.io v4, whose packets are text
+// separated by the record separator, with base64 binary messages.
+func NewV4() *Payload {
+	ret := New(false)
+	ret.encoder.v4 = true
+	ret.decoder.v4 = true
+	return ret
+}
+
 // FeedIn feeds in a new reader for NextReader.
 // Multi-FeedIn needs be called sync.
 //
@@ -531,3 +531,59 @@ func TestPayloadNextClosePause(t *testing.T) {
 	should.Nil(err)
 	should.Equal([]byte{0x0, 0x1, 0xff, '6'}, b.Bytes())
 }
+
+func TestPayloadV4(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	p := NewV4()
+	defer p.Close()
+
+	go func() {
+		data := "2probe\x1e4héllo\x1ebAQI="
+		must.NoError(p.FeedIn(bytes.NewReader([]byte(data)), false))
+	}()
+
+	for _, want := range []struct {
+		ft   frame.Type
+		pt   packet.Type
+		data []byte
+	}{
+		{frame.String, packet.PING, []byte("probe")},
+		{frame.String, packet.MESSAGE, []byte("héllo")},
+		{frame.Binary, packet.MESSAGE, []byte{1, 2}},
+	} {
+		ft, pt, r, err := p.NextReader()
+		must.NoError(err)
+		should.Equal(want.ft, ft)
+		should.Equal(want.pt, pt)
+
+		b, err := ioutil.ReadAll(r)
+		must.NoError(err)
+		must.NoError(r.Close())
+		should.Equal(want.data, b)
+	}
+
+	flushed := make(chan []byte)
+	go func() {
+		for i := 0; i < 2; i++ {
+			buf := bytes.NewBuffer(nil)
+			must.NoError(p.FlushOut(buf))
+			flushed <- buf.Bytes()
+		}
+	}()
+
+	w, err := p.NextWriter(frame.String, packet.MESSAGE)
+	must.NoError(err)
+	_, err = w.Write([]byte("héllo"))
+	must.NoError(err)
+	must.NoError(w.Close())
+	should.Equal([]byte("4héllo"), <-flushed)
+
+	w, err = p.NextWriter(frame.Binary, packet.MESSAGE)
+	must.NoError(err)
+	_, err = w.Write([]byte{1, 2})
+	must.NoError(err)
+	must.NoError(w.Close())
+	should.Equal([]byte("bAQI="), <-flushed)
+}
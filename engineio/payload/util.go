@@ -1,6 +1,12 @@
 package payload
 
-import "bytes"
+import (
+	"bytes"
+	"io"
+)
+
+// recordSeparator separates the packets of a payload of engine.io v4.
+const recordSeparator = 0x1e
 
 func writeBinaryLen(l int64, w *bytes.Buffer) error {
 	if l <= 0 {
@@ -87,3 +93,32 @@ func readTextLen(r byteReader) (int64, error) {
 	}
 	return ret, nil
 }
+
+// separatedReader reads r till the record separator, which is consumed.
+type separatedReader struct {
+	r    byteReader
+	done bool
+}
+
+func (s *separatedReader) Read(p []byte) (int, error) {
+	if s.done {
+		return 0, io.EOF
+	}
+
+	for i := range p {
+		b, err := s.r.ReadByte()
+		if err == io.EOF || (err == nil && b == recordSeparator) {
+			s.done = true
+			if i == 0 {
+				return 0, io.EOF
+			}
+			return i, nil
+		}
+		if err != nil {
+			return i, err
+		}
+		p[i] = b
+	}
+
+	return len(p), nil
+}
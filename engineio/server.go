@@ -1,10 +1,9 @@
 package engineio
 
 import (
-	"context"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"sync"
@@ -14,6 +13,8 @@ import (
 
 	"github.com/googollee/go-socket.io/engineio/session"
 	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/metrics"
 )
 
 // Server is instance of server
@@ -26,8 +27,14 @@ type Server struct {
 
 	requestChecker CheckerFunc
 	connInitor     ConnInitorFunc
-
-	connChan  chan Conn
+	cors           *CORSOptions
+	metrics        metrics.Metrics
+	logger         *slog.Logger
+
+	connChan chan Conn
+	// quit is closed once the server is closed, for the sessions not to be
+	// sent to connChan anymore.
+	quit      chan struct{}
 	closeOnce sync.Once
 }
 
@@ -39,26 +46,31 @@ func NewServer(opts *Options) *Server {
 		pingTimeout:    opts.getPingTimeout(),
 		requestChecker: opts.getRequestChecker(),
 		connInitor:     opts.getConnInitor(),
+		cors:           opts.getCORS(),
+		metrics:        opts.getMetrics(),
+		logger:         opts.getLogger(),
 		sessions:       session.NewManager(opts.getSessionIDGenerator()),
 		connChan:       make(chan Conn, 1),
+		quit:           make(chan struct{}),
 	}
 }
 
 // Close closes server.
 func (s *Server) Close() error {
 	s.closeOnce.Do(func() {
-		close(s.connChan)
+		close(s.quit)
 	})
 	return nil
 }
 
 // Accept accepts a connection.
 func (s *Server) Accept() (Conn, error) {
-	c := <-s.connChan
-	if c == nil {
+	select {
+	case c := <-s.connChan:
+		return c, nil
+	case <-s.quit:
 		return nil, io.EOF
 	}
-	return c, nil
 }
 
 func (s *Server) Addr() net.Addr {
@@ -66,6 +78,13 @@ func (s *Server) Addr() net.Addr {
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.cors != nil {
+		var ok bool
+		if r, ok = s.cors.serve(w, r); !ok {
+			return
+		}
+	}
+
 	query := r.URL.Query()
 
 	reqTransport := query.Get("transport")
@@ -86,6 +105,8 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	sid := query.Get("sid")
+	r = r.WithContext(logger.NewContext(r.Context(), s.logger.With(logger.SIDKey, sid, logger.TransportKey, reqTransport)))
+
 	reqSession, ok := s.sessions.Get(sid)
 	// if we can't find session in current session pool, let's create this. behaviour for new connections
 	if !ok {
@@ -100,7 +121,7 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		reqSession, err = s.newSession(r.Context(), transportConn, reqTransport)
+		reqSession, err = s.newSession(r, transportConn, reqTransport)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("create new session err: %s", err.Error()), http.StatusBadRequest)
 			return
@@ -139,10 +160,24 @@ func (s *Server) Count() int {
 
 // Remove session from sessions pool. Experimental API.
 func (s *Server) Remove(sid string) {
-	s.sessions.Remove(sid)
+	if ses, ok := s.sessions.Remove(sid); ok {
+		// the session is counted with the transport it's opened with, which
+		// isn't the one it's served by after an upgrade.
+		s.metrics.SessionClosed(ses.Handshake().Transport)
+	}
+}
+
+// Metrics returns the metrics which the server reports to.
+func (s *Server) Metrics() metrics.Metrics {
+	return s.metrics
 }
 
-func (s *Server) newSession(_ context.Context, conn transport.Conn, reqTransport string) (*session.Session, error) {
+// Logger returns the logger of the server.
+func (s *Server) Logger() *slog.Logger {
+	return s.logger
+}
+
+func (s *Server) newSession(r *http.Request, conn transport.Conn, reqTransport string) (*session.Session, error) {
 	params := transport.ConnParameters{
 		PingInterval: s.pingInterval,
 		PingTimeout:  s.pingTimeout,
@@ -150,21 +185,26 @@ func (s *Server) newSession(_ context.Context, conn transport.Conn, reqTransport
 	}
 
 	sid := s.sessions.NewID()
-	newSession, err := session.New(conn, sid, reqTransport, params)
+	newSession, err := session.New(conn, sid, reqTransport, params, session.NewHandshake(r, reqTransport), s.metrics, s.logger)
 	if err != nil {
 		return nil, err
 	}
 
 	go func(newSession *session.Session) {
-		if err = newSession.InitSession(); err != nil {
-			log.Println("init new session:", err)
+		if err := newSession.InitSession(); err != nil {
+			s.logger.Error("init new session", logger.SIDKey, sid, logger.TransportKey, reqTransport, logger.Err(err))
 
 			return
 		}
 
 		s.sessions.Add(newSession)
+		s.metrics.SessionOpened(reqTransport)
 
-		s.connChan <- newSession
+		select {
+		case s.connChan <- newSession:
+		case <-s.quit:
+			_ = newSession.Close()
+		}
 	}(newSession)
 
 	return newSession, nil
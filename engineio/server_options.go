@@ -1,10 +1,14 @@
 package engineio
 
 import (
-	"github.com/googollee/go-socket.io/engineio/session"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/googollee/go-socket.io/engineio/session"
+	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/metrics"
+
 	"github.com/googollee/go-socket.io/engineio/transport"
 	"github.com/googollee/go-socket.io/engineio/transport/polling"
 	"github.com/googollee/go-socket.io/engineio/transport/websocket"
@@ -15,11 +19,26 @@ type Options struct {
 	PingTimeout  time.Duration
 	PingInterval time.Duration
 
+	// Transports are the transports of the sessions, which are upgraded in
+	// their order, like polling to websocket, then to webtransport. Polling
+	// and websocket by default.
 	Transports         []transport.Transport
 	SessionIDGenerator session.IDGenerator
 
 	RequestChecker CheckerFunc
 	ConnInitor     ConnInitorFunc
+
+	// CORS are the cross-origin requests allowed, instead of the origin
+	// checks of the transports. They aren't handled by the server if nil.
+	CORS *CORSOptions
+
+	// Metrics receives measurements of the server. It discards all
+	// measurements by default.
+	Metrics metrics.Metrics
+
+	// Logger logs the server, with the session ID and transport as
+	// attributes. logger.Log is used by default.
+	Logger *slog.Logger
 }
 
 func (c *Options) getRequestChecker() CheckerFunc {
@@ -29,6 +48,13 @@ func (c *Options) getRequestChecker() CheckerFunc {
 	return defaultChecker
 }
 
+func (c *Options) getCORS() *CORSOptions {
+	if c != nil {
+		return c.CORS
+	}
+	return nil
+}
+
 func (c *Options) getConnInitor() ConnInitorFunc {
 	if c != nil && c.ConnInitor != nil {
 		return c.ConnInitor
@@ -67,6 +93,20 @@ func (c *Options) getSessionIDGenerator() session.IDGenerator {
 	return &session.DefaultIDGenerator{}
 }
 
+func (c *Options) getMetrics() metrics.Metrics {
+	if c != nil && c.Metrics != nil {
+		return c.Metrics
+	}
+	return metrics.Nop{}
+}
+
+func (c *Options) getLogger() *slog.Logger {
+	if c != nil && c.Logger != nil {
+		return c.Logger
+	}
+	return logger.Log
+}
+
 func defaultChecker(*http.Request) (http.Header, error) {
 	return nil, nil
 }
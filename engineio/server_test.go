@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -17,8 +18,10 @@ import (
 	"github.com/googollee/go-socket.io/engineio/packet"
 	"github.com/googollee/go-socket.io/engineio/session"
 	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/engineio/transport/memory"
 	"github.com/googollee/go-socket.io/engineio/transport/polling"
 	"github.com/googollee/go-socket.io/engineio/transport/websocket"
+	"github.com/googollee/go-socket.io/metrics"
 )
 
 func TestEnginePolling(t *testing.T) {
@@ -65,6 +68,8 @@ func TestEnginePolling(t *testing.T) {
 
 	dialer := Dialer{
 		Transports: []transport.Transport{polling.Default},
+		// the server speaks engine.io v3.
+		Version: 3,
 	}
 	header := http.Header{}
 	header.Set("X-EIO-Test", "client")
@@ -149,6 +154,7 @@ func TestEngineWebsocket(t *testing.T) {
 
 	dialer := Dialer{
 		Transports: []transport.Transport{websocket.Default},
+		Version:    3,
 	}
 	header := http.Header{}
 	header.Set("X-EIO-Test", "client")
@@ -325,3 +331,245 @@ func TestEngineUpgrade(t *testing.T) {
 
 	must.NoError(ws.Close())
 }
+
+func TestEngineDialUpgrade(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	svr := NewServer(nil)
+	defer func() {
+		must.NoError(svr.Close())
+	}()
+
+	httpSvr := httptest.NewServer(svr)
+	defer httpSvr.Close()
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		conn, err := svr.Accept()
+		must.NoError(err)
+		defer func() {
+			must.NoError(conn.Close())
+		}()
+
+		ft, r, err := conn.NextReader()
+		must.NoError(err)
+		should.Equal(session.TEXT, ft)
+
+		b, err := ioutil.ReadAll(r)
+		must.NoError(err)
+		should.Equal("hello你好", string(b))
+		should.Equal("websocket", conn.Transport())
+
+		must.NoError(r.Close())
+
+		w, err := conn.NextWriter(session.BINARY)
+		must.NoError(err)
+
+		_, err = w.Write([]byte{1, 2, 3, 4})
+		must.NoError(err)
+		must.NoError(w.Close())
+	}()
+
+	dialer := Dialer{
+		Transports: []transport.Transport{polling.Default, websocket.Default},
+		Version:    3,
+	}
+
+	cnt, err := dialer.Dial(httpSvr.URL, nil)
+	must.NoError(err)
+	should.Equal("polling", cnt.Handshake().Transport)
+
+	deadline := time.Now().Add(time.Second)
+	for cnt.Transport() != "websocket" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	must.Equal("websocket", cnt.Transport())
+
+	w, err := cnt.NextWriter(session.TEXT)
+	must.NoError(err)
+
+	_, err = w.Write([]byte("hello你好"))
+	must.NoError(err)
+	must.NoError(w.Close())
+
+	ft, r, err := cnt.NextReader()
+	must.NoError(err)
+	should.Equal(session.BINARY, ft)
+
+	b, err := ioutil.ReadAll(r)
+	must.NoError(err)
+	should.Equal([]byte{1, 2, 3, 4}, b)
+
+	must.NoError(r.Close())
+
+	wg.Wait()
+
+	must.NoError(cnt.Close())
+}
+
+func TestEngineMemoryUpgrade(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	from := &memory.Transport{}
+	to := &memory.Transport{Alias: "memory2"}
+
+	svr := NewServer(&Options{
+		Transports: []transport.Transport{from, to},
+	})
+	defer func() {
+		must.NoError(svr.Close())
+	}()
+
+	from.Handler = svr
+	to.Handler = svr
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		conn, err := svr.Accept()
+		must.NoError(err)
+		defer func() {
+			must.NoError(conn.Close())
+		}()
+
+		ft, r, err := conn.NextReader()
+		must.NoError(err)
+		should.Equal(session.TEXT, ft)
+
+		b, err := ioutil.ReadAll(r)
+		must.NoError(err)
+		should.Equal("hello你好", string(b))
+		should.Equal("memory2", conn.Transport())
+
+		must.NoError(r.Close())
+
+		w, err := conn.NextWriter(session.BINARY)
+		must.NoError(err)
+
+		_, err = w.Write([]byte{1, 2, 3, 4})
+		must.NoError(err)
+		must.NoError(w.Close())
+	}()
+
+	dialer := Dialer{
+		Transports: []transport.Transport{from, to},
+		Version:    3,
+	}
+
+	cnt, err := dialer.Dial("http://memory/engine.io/", nil)
+	must.NoError(err)
+	should.Equal("memory", cnt.Handshake().Transport)
+
+	deadline := time.Now().Add(time.Second)
+	for cnt.Transport() != "memory2" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	must.Equal("memory2", cnt.Transport())
+
+	w, err := cnt.NextWriter(session.TEXT)
+	must.NoError(err)
+
+	_, err = w.Write([]byte("hello你好"))
+	must.NoError(err)
+	must.NoError(w.Close())
+
+	ft, r, err := cnt.NextReader()
+	must.NoError(err)
+	should.Equal(session.BINARY, ft)
+
+	b, err := ioutil.ReadAll(r)
+	must.NoError(err)
+	should.Equal([]byte{1, 2, 3, 4}, b)
+
+	must.NoError(r.Close())
+
+	wg.Wait()
+
+	must.NoError(cnt.Close())
+}
+
+type sessionMetrics struct {
+	metrics.Nop
+
+	mu     sync.Mutex
+	opened []string
+	closed []string
+}
+
+func (m *sessionMetrics) SessionOpened(transport string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.opened = append(m.opened, transport)
+}
+
+func (m *sessionMetrics) SessionClosed(transport string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = append(m.closed, transport)
+}
+
+func (m *sessionMetrics) sessions() ([]string, []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]string(nil), m.opened...), append([]string(nil), m.closed...)
+}
+
+func TestEngineSessionMetricsUpgrade(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	from := &memory.Transport{}
+	to := &memory.Transport{Alias: "memory2"}
+	m := &sessionMetrics{}
+
+	svr := NewServer(&Options{
+		Transports: []transport.Transport{from, to},
+		Metrics:    m,
+	})
+	defer func() {
+		must.NoError(svr.Close())
+	}()
+
+	from.Handler = svr
+	to.Handler = svr
+
+	dialer := Dialer{
+		Transports: []transport.Transport{from, to},
+		Version:    3,
+	}
+
+	cnt, err := dialer.Dial("http://memory/engine.io/", nil)
+	must.NoError(err)
+
+	conn, err := svr.Accept()
+	must.NoError(err)
+
+	deadline := time.Now().Add(time.Second)
+	for conn.Transport() != "memory2" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	must.Equal("memory2", conn.Transport())
+
+	must.NoError(conn.Close())
+	must.NoError(cnt.Close())
+	svr.Remove(conn.ID())
+
+	// the session upgraded is closed with the transport it's opened with.
+	opened, closed := m.sessions()
+	should.Equal([]string{"memory"}, opened)
+	should.Equal([]string{"memory"}, closed)
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"io"
+
+	"github.com/googollee/go-socket.io/engineio/transport"
+)
+
+// countReader counts bytes read from a frame, and reports the count when the
+// frame is closed.
+type countReader struct {
+	io.ReadCloser
+
+	n      int
+	report func(n int)
+}
+
+func newCountReader(r io.ReadCloser, report func(n int)) *countReader {
+	return &countReader{
+		ReadCloser: r,
+		report:     report,
+	}
+}
+
+func (r *countReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += n
+	return n, err
+}
+
+func (r *countReader) Close() error {
+	if r.report != nil {
+		r.report(r.n)
+		r.report = nil
+	}
+
+	return r.ReadCloser.Close()
+}
+
+// countWriter counts bytes written to a frame, and reports the count when the
+// frame is closed.
+type countWriter struct {
+	io.WriteCloser
+
+	n      int
+	report func(n int)
+}
+
+func newCountWriter(w io.WriteCloser, report func(n int)) *countWriter {
+	return &countWriter{
+		WriteCloser: w,
+		report:      report,
+	}
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.n += n
+	return n, err
+}
+
+// SetCompress passes compress to the transport writer, if it compresses frames.
+func (w *countWriter) SetCompress(compress bool) {
+	if c, ok := w.WriteCloser.(transport.Compressor); ok {
+		c.SetCompress(compress)
+	}
+}
+
+func (w *countWriter) Close() error {
+	if w.report != nil {
+		w.report(w.n)
+		w.report = nil
+	}
+
+	return w.WriteCloser.Close()
+}
@@ -0,0 +1,50 @@
+package session
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Handshake is the details of the request which opened a session. It is
+// captured once when the session is created and never changes after.
+type Handshake struct {
+	// Time is when the session is created.
+	Time time.Time
+	// URL is the url of the request which opened the session.
+	URL url.URL
+	// Query is the parsed query of URL.
+	Query url.Values
+	// Header is the header of the request which opened the session.
+	Header http.Header
+	// Address is the remote address of the peer.
+	Address string
+	// Secure reports whether the session is opened over TLS.
+	Secure bool
+	// Transport is the transport used when the session is opened, the
+	// session may upgrade to another transport later.
+	Transport string
+	// PeerCertificates are the certificates presented by the peer, which
+	// are only set with mutual TLS.
+	PeerCertificates []*x509.Certificate
+}
+
+// NewHandshake returns the handshake of a session opened by r with transport.
+func NewHandshake(r *http.Request, transport string) Handshake {
+	ret := Handshake{
+		Time:      time.Now(),
+		URL:       *r.URL,
+		Query:     r.URL.Query(),
+		Header:    r.Header.Clone(),
+		Address:   r.RemoteAddr,
+		Secure:    r.TLS != nil,
+		Transport: transport,
+	}
+
+	if r.TLS != nil {
+		ret.PeerCertificates = r.TLS.PeerCertificates
+	}
+
+	return ret
+}
@@ -0,0 +1,35 @@
+package session
+
+import (
+	"crypto/x509"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHandshake(t *testing.T) {
+	should := assert.New(t)
+
+	r := httptest.NewRequest("GET", "https://example.com/socket.io/?EIO=3&transport=polling&token=abc", nil)
+	r.Header.Set("X-Eio-Test", "handshake")
+	r.TLS.PeerCertificates = []*x509.Certificate{{}}
+
+	h := NewHandshake(r, "polling")
+
+	should.False(h.Time.IsZero())
+	should.Equal("/socket.io/", h.URL.Path)
+	should.Equal("abc", h.Query.Get("token"))
+	should.Equal("handshake", h.Header.Get("X-Eio-Test"))
+	should.Equal(r.RemoteAddr, h.Address)
+	should.True(h.Secure)
+	should.Equal("polling", h.Transport)
+	should.Len(h.PeerCertificates, 1)
+
+	r = httptest.NewRequest("GET", "http://example.com/socket.io/", nil)
+
+	h = NewHandshake(r, "websocket")
+
+	should.False(h.Secure)
+	should.Nil(h.PeerCertificates)
+}
@@ -2,6 +2,7 @@ package session
 
 import (
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
@@ -13,6 +14,7 @@ import (
 	"github.com/googollee/go-socket.io/engineio/payload"
 	"github.com/googollee/go-socket.io/engineio/transport"
 	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/metrics"
 )
 
 // Pauser is connection which can be paused and resumes.
@@ -25,24 +27,40 @@ type Session struct {
 	conn      transport.Conn
 	params    transport.ConnParameters
 	transport string
+	handshake Handshake
+	metrics   metrics.Metrics
+	logger    *slog.Logger
 
 	context interface{}
 
 	upgradeLocker sync.RWMutex
+	// upgradeDone is closed once the last upgrade is done.
+	upgradeDone chan struct{}
 }
 
-func New(conn transport.Conn, sid, transport string, params transport.ConnParameters) (*Session, error) {
+func New(conn transport.Conn, sid, transport string, params transport.ConnParameters, handshake Handshake, m metrics.Metrics, l *slog.Logger) (*Session, error) {
 	params.SID = sid
 
+	if m == nil {
+		m = metrics.Nop{}
+	}
+
+	if l == nil {
+		l = logger.Log
+	}
+
 	ses := &Session{
 		transport: transport,
 		conn:      conn,
 		params:    params,
+		handshake: handshake,
+		metrics:   m,
+		logger:    l.With(logger.SIDKey, sid),
 	}
 
 	if err := ses.setDeadline(); err != nil {
 		if closeErr := ses.Close(); closeErr != nil {
-			logger.Error("session close:", closeErr)
+			ses.log().Error("session close", logger.Err(closeErr))
 		}
 
 		return nil, err
@@ -63,6 +81,11 @@ func (s *Session) ID() string {
 	return s.params.SID
 }
 
+// Handshake returns the details of the request which opened the session.
+func (s *Session) Handshake() Handshake {
+	return s.handshake
+}
+
 func (s *Session) Transport() string {
 	s.upgradeLocker.RLock()
 	defer s.upgradeLocker.RUnlock()
@@ -70,6 +93,11 @@ func (s *Session) Transport() string {
 	return s.transport
 }
 
+// log gives the logger of the session with its current transport.
+func (s *Session) log() *slog.Logger {
+	return s.logger.With(logger.TransportKey, s.Transport())
+}
+
 func (s *Session) Close() error {
 	s.upgradeLocker.RLock()
 	defer s.upgradeLocker.RUnlock()
@@ -85,7 +113,7 @@ func (s *Session) NextReader() (FrameType, io.ReadCloser, error) {
 		ft, pt, r, err := s.nextReader()
 		if err != nil {
 			if closeErr := s.Close(); closeErr != nil {
-				logger.Error("close session after next reader:", closeErr)
+				s.log().Error("close session after next reader", logger.Err(closeErr))
 			}
 
 			return 0, nil, err
@@ -103,12 +131,12 @@ func (s *Session) NextReader() (FrameType, io.ReadCloser, error) {
 				_, err = io.Copy(w, r)
 				// unlocks the wrapped connection's FrameWriter
 				if closeErr := w.Close(); closeErr != nil {
-					logger.Error("close writer after write pong packet:", closeErr)
+					s.log().Error("close writer after write pong packet", logger.Err(closeErr))
 				}
 
 				// unlocks the wrapped connection's FrameReader
 				if closeErr := r.Close(); closeErr != nil {
-					logger.Error("close reader:", closeErr)
+					s.log().Error("close reader", logger.Err(closeErr))
 				}
 
 				return err
@@ -116,7 +144,7 @@ func (s *Session) NextReader() (FrameType, io.ReadCloser, error) {
 
 			if err != nil {
 				if closeErr := s.Close(); closeErr != nil {
-					logger.Error("close session:", closeErr)
+					s.log().Error("close session", logger.Err(closeErr))
 				}
 
 				return 0, nil, err
@@ -124,7 +152,7 @@ func (s *Session) NextReader() (FrameType, io.ReadCloser, error) {
 			// Read another frame.
 			if err := s.setDeadline(); err != nil {
 				if closeErr := s.Close(); closeErr != nil {
-					logger.Error("close session after set deadline:", closeErr)
+					s.log().Error("close session after set deadline", logger.Err(closeErr))
 				}
 
 				return 0, nil, err
@@ -133,11 +161,11 @@ func (s *Session) NextReader() (FrameType, io.ReadCloser, error) {
 		case packet.CLOSE:
 			// unlocks the wrapped connection's FrameReader
 			if err = r.Close(); err != nil {
-				logger.Error("close reader on packet close:", err)
+				s.log().Error("close reader on packet close", logger.Err(err))
 			}
 
 			if err = s.Close(); err != nil {
-				logger.Error("close session on packet close:", err)
+				s.log().Error("close session on packet close", logger.Err(err))
 			}
 
 			return 0, nil, io.EOF
@@ -150,7 +178,7 @@ func (s *Session) NextReader() (FrameType, io.ReadCloser, error) {
 		default:
 			// Unknown packet type. Close reader and try again.
 			if err = r.Close(); err != nil {
-				logger.Error("close reader on unknown packet:", err)
+				s.log().Error("close reader on unknown packet", logger.Err(err))
 			}
 		}
 	}
@@ -192,14 +220,23 @@ func (s *Session) NextWriter(typ FrameType) (io.WriteCloser, error) {
 }
 
 func (s *Session) Upgrade(transport string, conn transport.Conn) {
-	go s.upgrading(transport, conn)
+	done := make(chan struct{})
+
+	s.upgradeLocker.Lock()
+	s.upgradeDone = done
+	s.upgradeLocker.Unlock()
+
+	go func() {
+		defer close(done)
+		s.upgrading(transport, conn)
+	}()
 }
 
 func (s *Session) InitSession() error {
 	w, err := s.nextWriter(frame.String, packet.OPEN)
 	if err != nil {
 		if closeErr := s.Close(); closeErr != nil {
-			logger.Error("close session with string frame and packet open:", closeErr)
+			s.log().Error("close session with string frame and packet open", logger.Err(closeErr))
 		}
 
 		return err
@@ -207,11 +244,11 @@ func (s *Session) InitSession() error {
 
 	if _, err := s.params.WriteTo(w); err != nil {
 		if closeErr := w.Close(); closeErr != nil {
-			logger.Error("close writer:", closeErr)
+			s.log().Error("close writer", logger.Err(closeErr))
 		}
 
 		if closeErr := s.Close(); closeErr != nil {
-			logger.Error("close session:", closeErr)
+			s.log().Error("close session", logger.Err(closeErr))
 		}
 
 		return err
@@ -219,7 +256,7 @@ func (s *Session) InitSession() error {
 
 	if err := w.Close(); err != nil {
 		if closeErr := s.Close(); closeErr != nil {
-			logger.Error("close session:", closeErr)
+			s.log().Error("close session", logger.Err(closeErr))
 		}
 
 		return err
@@ -242,6 +279,7 @@ func (s *Session) nextReader() (frame.Type, packet.Type, io.ReadCloser, error) {
 	for {
 		s.upgradeLocker.RLock()
 		conn := s.conn
+		name := s.transport
 		s.upgradeLocker.RUnlock()
 
 		ft, pt, r, err := conn.NextReader()
@@ -249,16 +287,47 @@ func (s *Session) nextReader() (frame.Type, packet.Type, io.ReadCloser, error) {
 			if op, ok := err.(payload.Error); ok && op.Temporary() {
 				continue
 			}
+			// the old conn is closed once upgraded, which the client may
+			// do before the upgrade is done.
+			if s.upgraded(conn) || s.awaitUpgrade(conn) {
+				continue
+			}
 			return 0, 0, nil, err
 		}
-		return ft, pt, r, nil
+		return ft, pt, newCountReader(r, func(n int) {
+			s.metrics.BytesReceived(name, n)
+		}), nil
 	}
 }
 
+// awaitUpgrade waits for the last upgrade to be done, and tells if the session
+// was upgraded from conn.
+func (s *Session) awaitUpgrade(conn transport.Conn) bool {
+	s.upgradeLocker.RLock()
+	done := s.upgradeDone
+	s.upgradeLocker.RUnlock()
+
+	if done == nil {
+		return false
+	}
+
+	<-done
+	return s.upgraded(conn)
+}
+
+// upgraded tells if the session was upgraded from conn.
+func (s *Session) upgraded(conn transport.Conn) bool {
+	s.upgradeLocker.RLock()
+	defer s.upgradeLocker.RUnlock()
+
+	return s.conn != conn
+}
+
 func (s *Session) nextWriter(ft frame.Type, pt packet.Type) (io.WriteCloser, error) {
 	for {
 		s.upgradeLocker.RLock()
 		conn := s.conn
+		name := s.transport
 		s.upgradeLocker.RUnlock()
 
 		w, err := conn.NextWriter(ft, pt)
@@ -270,7 +339,9 @@ func (s *Session) nextWriter(ft frame.Type, pt packet.Type) (io.WriteCloser, err
 		}
 		// Caller must Close the WriteCloser to unlock the connection's
 		// FrameWriter when finished writing.
-		return w, nil
+		return newCountWriter(w, func(n int) {
+			s.metrics.BytesSent(name, n)
+		}), nil
 	}
 }
 
@@ -289,13 +360,26 @@ func (s *Session) setDeadline() error {
 }
 
 func (s *Session) upgrading(t string, conn transport.Conn) {
+	from := s.Transport()
+	upgraded := false
+
+	l := s.logger.With(logger.TransportKey, t)
+
+	defer func() {
+		if upgraded {
+			s.metrics.UpgradeSucceeded(from, t)
+		} else {
+			s.metrics.UpgradeFailed(from, t)
+		}
+	}()
+
 	// Read a ping from the client.
 	err := conn.SetReadDeadline(time.Now().Add(s.params.PingTimeout))
 	if err != nil {
-		logger.Error("set read deadline:", err)
+		l.Error("set read deadline", logger.Err(err))
 
 		if closeErr := conn.Close(); closeErr != nil {
-			logger.Error("close connect after set read deadline:", closeErr)
+			l.Error("close connect after set read deadline", logger.Err(closeErr))
 		}
 
 		return
@@ -303,10 +387,10 @@ func (s *Session) upgrading(t string, conn transport.Conn) {
 
 	ft, pt, r, err := conn.NextReader()
 	if err != nil {
-		logger.Error("get next reader:", err)
+		l.Error("get next reader", logger.Err(err))
 
 		if closeErr := conn.Close(); closeErr != nil {
-			logger.Error("close connect after get next reader:", closeErr)
+			l.Error("close connect after get next reader", logger.Err(closeErr))
 		}
 
 		return
@@ -314,11 +398,11 @@ func (s *Session) upgrading(t string, conn transport.Conn) {
 
 	if pt != packet.PING {
 		if err := r.Close(); err != nil {
-			logger.Error("close reade:", err)
+			l.Error("close reade", logger.Err(err))
 		}
 
 		if err := conn.Close(); err != nil {
-			logger.Error("close connect:", err)
+			l.Error("close connect", logger.Err(err))
 		}
 
 		return
@@ -328,14 +412,14 @@ func (s *Session) upgrading(t string, conn transport.Conn) {
 	// Sent a pong in reply.
 	err = conn.SetWriteDeadline(time.Now().Add(s.params.PingTimeout))
 	if err != nil {
-		logger.Error("set write deadline:", err)
+		l.Error("set write deadline", logger.Err(err))
 
 		if closeErr := r.Close(); closeErr != nil {
-			logger.Error("close reader:", closeErr)
+			l.Error("close reader", logger.Err(closeErr))
 		}
 
 		if closeErr := conn.Close(); closeErr != nil {
-			logger.Error("close connect:", closeErr)
+			l.Error("close connect", logger.Err(closeErr))
 		}
 
 		return
@@ -343,14 +427,14 @@ func (s *Session) upgrading(t string, conn transport.Conn) {
 
 	w, err := conn.NextWriter(ft, packet.PONG)
 	if err != nil {
-		logger.Error("get next writer with pong packet:", err)
+		l.Error("get next writer with pong packet", logger.Err(err))
 
 		if closeErr := r.Close(); closeErr != nil {
-			logger.Error("close reader:", closeErr)
+			l.Error("close reader", logger.Err(closeErr))
 		}
 
 		if closeErr := conn.Close(); closeErr != nil {
-			logger.Error("close connect:", closeErr)
+			l.Error("close connect", logger.Err(closeErr))
 		}
 
 		return
@@ -358,42 +442,42 @@ func (s *Session) upgrading(t string, conn transport.Conn) {
 
 	// echo
 	if _, err = io.Copy(w, r); err != nil {
-		logger.Error("copy from reader to writer:", err)
+		l.Error("copy from reader to writer", logger.Err(err))
 
 		if closeErr := w.Close(); closeErr != nil {
-			logger.Error("close writer:", closeErr)
+			l.Error("close writer", logger.Err(closeErr))
 		}
 
 		if closeErr := r.Close(); closeErr != nil {
-			logger.Error("close reader:", closeErr)
+			l.Error("close reader", logger.Err(closeErr))
 		}
 
 		if closeErr := conn.Close(); closeErr != nil {
-			logger.Error("close connect:", closeErr)
+			l.Error("close connect", logger.Err(closeErr))
 		}
 
 		return
 	}
 
 	if err = r.Close(); err != nil {
-		logger.Error("close reader:", err)
+		l.Error("close reader", logger.Err(err))
 
 		if closeErr := w.Close(); closeErr != nil {
-			logger.Error("close writer:", closeErr)
+			l.Error("close writer", logger.Err(closeErr))
 		}
 
 		if closeErr := conn.Close(); closeErr != nil {
-			logger.Error("close connect:", closeErr)
+			l.Error("close connect", logger.Err(closeErr))
 		}
 
 		return
 	}
 
 	if err = w.Close(); err != nil {
-		logger.Error("close writer:", err)
+		l.Error("close writer", logger.Err(err))
 
 		if closeErr := conn.Close(); closeErr != nil {
-			logger.Error("close connect:", closeErr)
+			l.Error("close connect", logger.Err(closeErr))
 		}
 
 		return
@@ -408,7 +492,7 @@ func (s *Session) upgrading(t string, conn transport.Conn) {
 	if !ok {
 		// old transport doesn't support upgrading
 		if closeErr := conn.Close(); closeErr != nil {
-			logger.Error("close connect after get pauser:", closeErr)
+			l.Error("close connect after get pauser", logger.Err(closeErr))
 		}
 
 		return
@@ -426,10 +510,10 @@ func (s *Session) upgrading(t string, conn transport.Conn) {
 	// Check for upgrade packet from the client.
 	_, pt, r, err = conn.NextReader()
 	if err != nil {
-		logger.Error("get next reader:", err)
+		l.Error("get next reader", logger.Err(err))
 
 		if closeErr := conn.Close(); closeErr != nil {
-			logger.Error("close connect:", closeErr)
+			l.Error("close connect", logger.Err(closeErr))
 		}
 
 		return
@@ -437,21 +521,21 @@ func (s *Session) upgrading(t string, conn transport.Conn) {
 
 	if pt != packet.UPGRADE {
 		if closeErr := r.Close(); closeErr != nil {
-			logger.Error("close reader:", closeErr)
+			l.Error("close reader", logger.Err(closeErr))
 		}
 
 		if closeErr := conn.Close(); closeErr != nil {
-			logger.Error("close connect:", closeErr)
+			l.Error("close connect", logger.Err(closeErr))
 		}
 
 		return
 	}
 
 	if err = r.Close(); err != nil {
-		logger.Error("close reader:", err)
+		l.Error("close reader", logger.Err(err))
 
 		if closeErr := conn.Close(); closeErr != nil {
-			logger.Error("close connect:", closeErr)
+			l.Error("close connect", logger.Err(closeErr))
 		}
 
 		return
@@ -464,8 +548,9 @@ func (s *Session) upgrading(t string, conn transport.Conn) {
 	s.upgradeLocker.Unlock()
 
 	p = nil
+	upgraded = true
 
 	if closeErr := old.Close(); closeErr != nil {
-		logger.Error("close old connection:", closeErr)
+		l.Error("close old connection", logger.Err(closeErr))
 	}
 }
@@ -36,14 +36,18 @@ func (m *Manager) Get(sid string) (*Session, bool) {
 	return s, ok
 }
 
-func (m *Manager) Remove(sid string) {
+// Remove removes the session of sid, and returns the removed session. ok is
+// false if the session has been removed before.
+func (m *Manager) Remove(sid string) (s *Session, ok bool) {
 	m.locker.Lock()
 	defer m.locker.Unlock()
 
-	if _, ok := m.sessions[sid]; !ok {
-		return
+	if s, ok = m.sessions[sid]; !ok {
+		return nil, false
 	}
 	delete(m.sessions, sid)
+
+	return s, true
 }
 
 func (m *Manager) Count() int {
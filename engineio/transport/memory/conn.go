@@ -0,0 +1,334 @@
+package memory
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/googollee/go-socket.io/engineio/frame"
+	"github.com/googollee/go-socket.io/engineio/packet"
+	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/engineio/transport/utils"
+)
+
+// bufferSize is the number of the frames written to a side of a pipe before
+// it's read, after which the writes wait.
+const bufferSize = 16
+
+// message is a frame sent through a pipe.
+type message struct {
+	ft   frame.Type
+	pt   packet.Type
+	data []byte
+}
+
+// ErrTimeout is the error of the reads and writes whose deadline passed.
+var ErrTimeout error = timeoutError{}
+
+// timeoutError is a timeout, like the ones of net.Conn, which isn't temporary
+// for the session not to retry.
+type timeoutError struct{}
+
+func (timeoutError) Error() string {
+	return "i/o timeout"
+}
+
+func (timeoutError) Timeout() bool {
+	return true
+}
+
+func (timeoutError) Temporary() bool {
+	return false
+}
+
+// pausedError is the error of the writes to a paused conn which is closed,
+// which is temporary for the session to write to the conn it's upgraded to.
+type pausedError struct{}
+
+func (pausedError) Error() string {
+	return "paused"
+}
+
+func (pausedError) Temporary() bool {
+	return true
+}
+
+var errPaused error = pausedError{}
+
+type addr string
+
+func (addr) Network() string {
+	return "memory"
+}
+
+func (a addr) String() string {
+	return string(a)
+}
+
+var pipes uint64
+
+// conn is a side of a pipe.
+type conn struct {
+	url          url.URL
+	remoteHeader http.Header
+	local        addr
+	remote       addr
+	clock        utils.Clock
+
+	in         <-chan message
+	out        chan<- message
+	closed     chan struct{}
+	peerClosed <-chan struct{}
+	closeOnce  sync.Once
+
+	mu            sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+	// deadlineSet is closed once a deadline is set.
+	deadlineSet chan struct{}
+	// resumed is closed once the paused conn is resumed, nil if it isn't
+	// paused.
+	resumed chan struct{}
+}
+
+// pipe returns the client and server sides of a connection dialed to u with
+// header.
+func pipe(u url.URL, header http.Header, clock utils.Clock) (*conn, *conn) {
+	n := atomic.AddUint64(&pipes, 1)
+	clientAddr := addr(fmt.Sprintf("client-%d", n))
+	serverAddr := addr(fmt.Sprintf("server-%d", n))
+
+	toServer := make(chan message, bufferSize)
+	toClient := make(chan message, bufferSize)
+	clientClosed := make(chan struct{})
+	serverClosed := make(chan struct{})
+
+	client := &conn{
+		url:          u,
+		remoteHeader: http.Header{},
+		local:        clientAddr,
+		remote:       serverAddr,
+		clock:        clock,
+		in:           toClient,
+		out:          toServer,
+		closed:       clientClosed,
+		peerClosed:   serverClosed,
+		deadlineSet:  make(chan struct{}),
+	}
+
+	server := &conn{
+		url:          u,
+		remoteHeader: header.Clone(),
+		local:        serverAddr,
+		remote:       clientAddr,
+		clock:        clock,
+		in:           toServer,
+		out:          toClient,
+		closed:       serverClosed,
+		peerClosed:   clientClosed,
+		deadlineSet:  make(chan struct{}),
+	}
+
+	return client, server
+}
+
+func (c *conn) URL() url.URL {
+	return c.url
+}
+
+func (c *conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *conn) RemoteHeader() http.Header {
+	return c.remoteHeader
+}
+
+func (c *conn) SetReadDeadline(t time.Time) error {
+	c.setDeadline(&c.readDeadline, t)
+	return nil
+}
+
+func (c *conn) SetWriteDeadline(t time.Time) error {
+	c.setDeadline(&c.writeDeadline, t)
+	return nil
+}
+
+func (c *conn) setDeadline(deadline *time.Time, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	*deadline = t
+
+	close(c.deadlineSet)
+	c.deadlineSet = make(chan struct{})
+}
+
+// expiry gives the channel closed once the deadline passes on the clock, with
+// the func stopping its timer, and the channel closed once a deadline is set.
+func (c *conn) expiry(deadline *time.Time) (<-chan struct{}, func() bool, <-chan struct{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if deadline.IsZero() {
+		return nil, func() bool { return false }, c.deadlineSet, nil
+	}
+
+	d := deadline.Sub(c.clock.Now())
+	if d <= 0 {
+		return nil, nil, nil, ErrTimeout
+	}
+
+	expired := make(chan struct{})
+	stop := c.clock.AfterFunc(d, func() {
+		close(expired)
+	})
+
+	return expired, stop, c.deadlineSet, nil
+}
+
+func (c *conn) NextReader() (frame.Type, packet.Type, io.ReadCloser, error) {
+	for {
+		expired, stop, deadlineSet, err := c.expiry(&c.readDeadline)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+
+		select {
+		case m := <-c.in:
+			stop()
+			return m.ft, m.pt, io.NopCloser(bytes.NewReader(m.data)), nil
+		case <-c.closed:
+			stop()
+			return 0, 0, nil, io.EOF
+		case <-c.peerClosed:
+			stop()
+			// the frames written before the peer closed are read first.
+			select {
+			case m := <-c.in:
+				return m.ft, m.pt, io.NopCloser(bytes.NewReader(m.data)), nil
+			default:
+				return 0, 0, nil, io.EOF
+			}
+		case <-expired:
+		case <-deadlineSet:
+			stop()
+		}
+	}
+}
+
+func (c *conn) NextWriter(ft frame.Type, pt packet.Type) (io.WriteCloser, error) {
+	if ft != frame.String && ft != frame.Binary {
+		return nil, transport.ErrInvalidFrame
+	}
+
+	if err := c.waitResumed(); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-c.closed:
+		return nil, io.ErrClosedPipe
+	case <-c.peerClosed:
+		return nil, io.ErrClosedPipe
+	default:
+	}
+
+	return &writer{conn: c, ft: ft, pt: pt}, nil
+}
+
+// Pause makes the next writers wait for Resume. They fail with a temporary
+// error once the conn is closed, as it's by the session upgraded from it.
+func (c *conn) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resumed == nil {
+		c.resumed = make(chan struct{})
+	}
+}
+
+// Resume resumes the writers waiting since Pause.
+func (c *conn) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resumed != nil {
+		close(c.resumed)
+		c.resumed = nil
+	}
+}
+
+func (c *conn) waitResumed() error {
+	c.mu.Lock()
+	resumed := c.resumed
+	c.mu.Unlock()
+
+	if resumed == nil {
+		return nil
+	}
+
+	select {
+	case <-resumed:
+		return nil
+	case <-c.closed:
+		return errPaused
+	case <-c.peerClosed:
+		return io.ErrClosedPipe
+	}
+}
+
+// send sends m to the peer, until the write deadline passes.
+func (c *conn) send(m message) error {
+	for {
+		expired, stop, deadlineSet, err := c.expiry(&c.writeDeadline)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case c.out <- m:
+			stop()
+			return nil
+		case <-c.closed:
+			stop()
+			return io.ErrClosedPipe
+		case <-c.peerClosed:
+			stop()
+			return io.ErrClosedPipe
+		case <-expired:
+		case <-deadlineSet:
+			stop()
+		}
+	}
+}
+
+func (c *conn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+// writer buffers a frame, which is sent once it's closed.
+type writer struct {
+	bytes.Buffer
+
+	conn *conn
+	ft   frame.Type
+	pt   packet.Type
+}
+
+func (w *writer) Close() error {
+	return w.conn.send(message{ft: w.ft, pt: w.pt, data: w.Bytes()})
+}
@@ -0,0 +1,231 @@
+package memory
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/googollee/go-socket.io/engineio/frame"
+	"github.com/googollee/go-socket.io/engineio/packet"
+	"github.com/googollee/go-socket.io/engineio/payload"
+	"github.com/googollee/go-socket.io/engineio/transport"
+)
+
+var tests = []struct {
+	ft   frame.Type
+	pt   packet.Type
+	data []byte
+}{
+	{frame.String, packet.OPEN, []byte{}},
+	{frame.String, packet.MESSAGE, []byte("hello")},
+	{frame.Binary, packet.MESSAGE, []byte{1, 2, 3, 4}},
+}
+
+// dial dials a conn of t, and returns it with the conn accepted by the handler.
+func dial(t *testing.T, tr *Transport, header http.Header) (transport.Conn, transport.Conn) {
+	accepted := make(chan transport.Conn, 1)
+	tr.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := tr.Accept(w, r)
+		require.NoError(t, err)
+		accepted <- c
+	})
+
+	u, err := url.Parse("http://memory/engine.io/?EIO=3")
+	require.NoError(t, err)
+
+	cc, err := tr.Dial(u, header)
+	require.NoError(t, err)
+
+	return cc, <-accepted
+}
+
+func write(t *testing.T, c transport.Conn, ft frame.Type, pt packet.Type, data []byte) error {
+	w, err := c.NextWriter(ft, pt)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	require.NoError(t, err)
+
+	return w.Close()
+}
+
+func read(t *testing.T, c transport.Conn) (frame.Type, packet.Type, []byte) {
+	ft, pt, r, err := c.NextReader()
+	require.NoError(t, err)
+
+	b, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	return ft, pt, b
+}
+
+func TestMemory(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	tr := &Transport{}
+	should.Equal("memory", tr.Name())
+
+	header := http.Header{}
+	header.Set("X-Eio-Test", "client")
+
+	cc, sc := dial(t, tr, header)
+	defer cc.Close()
+	defer sc.Close()
+
+	should.Equal("client", sc.RemoteHeader().Get("X-Eio-Test"))
+	u := sc.URL()
+	should.Equal("memory", u.Query().Get("transport"))
+	should.Equal(cc.URL(), sc.URL())
+	should.Equal(cc.LocalAddr(), sc.RemoteAddr())
+	should.Equal(cc.RemoteAddr(), sc.LocalAddr())
+
+	for _, test := range tests {
+		must.NoError(write(t, cc, test.ft, test.pt, test.data))
+
+		ft, pt, b := read(t, sc)
+		should.Equal(test.ft, ft)
+		should.Equal(test.pt, pt)
+		should.Equal(test.data, b)
+
+		must.NoError(write(t, sc, test.ft, test.pt, test.data))
+
+		ft, pt, b = read(t, cc)
+		should.Equal(test.ft, ft)
+		should.Equal(test.pt, pt)
+		should.Equal(test.data, b)
+	}
+
+	_, err := cc.NextWriter(frame.Type(9), packet.MESSAGE)
+	should.Equal(transport.ErrInvalidFrame, err)
+}
+
+func TestMemoryDialError(t *testing.T) {
+	should := assert.New(t)
+
+	tr := &Transport{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "invalid transport", http.StatusBadRequest)
+		}),
+	}
+
+	u, err := url.Parse("http://memory/")
+	require.NoError(t, err)
+
+	_, err = tr.Dial(u, nil)
+	should.EqualError(err, "memory dial: 400 invalid transport")
+
+	_, err = tr.Accept(nil, &http.Request{})
+	should.Equal(errNotDialed, err)
+
+	_, err = (&Transport{}).Dial(u, nil)
+	should.Error(err)
+}
+
+func TestMemoryClose(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	cc, sc := dial(t, &Transport{}, nil)
+
+	must.NoError(write(t, cc, frame.String, packet.MESSAGE, []byte("last")))
+	must.NoError(cc.Close())
+	must.NoError(cc.Close())
+
+	// the frames written before closing are read.
+	_, _, b := read(t, sc)
+	should.Equal("last", string(b))
+
+	_, _, _, err := sc.NextReader()
+	should.Equal(io.EOF, err)
+	_, err = sc.NextWriter(frame.String, packet.MESSAGE)
+	should.Equal(io.ErrClosedPipe, err)
+
+	_, _, _, err = cc.NextReader()
+	should.Equal(io.EOF, err)
+}
+
+func TestMemoryDeadline(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	cc, sc := dial(t, &Transport{}, nil)
+	defer cc.Close()
+	defer sc.Close()
+
+	must.NoError(sc.SetReadDeadline(time.Now().Add(20 * time.Millisecond)))
+	start := time.Now()
+	_, _, _, err := sc.NextReader()
+	should.Equal(ErrTimeout, err)
+	should.True(err.(interface{ Timeout() bool }).Timeout())
+	should.False(err.(payload.Error).Temporary())
+	should.True(time.Since(start) >= 20*time.Millisecond)
+
+	// a deadline set while reading applies to the read.
+	must.NoError(sc.SetReadDeadline(time.Time{}))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = sc.SetReadDeadline(time.Now())
+	}()
+	_, _, _, err = sc.NextReader()
+	should.Equal(ErrTimeout, err)
+
+	// the writes wait once the frames aren't read.
+	must.NoError(cc.SetWriteDeadline(time.Now().Add(20 * time.Millisecond)))
+	for i := 0; i < bufferSize; i++ {
+		must.NoError(write(t, cc, frame.String, packet.MESSAGE, nil))
+	}
+	should.Equal(ErrTimeout, write(t, cc, frame.String, packet.MESSAGE, nil))
+}
+
+func TestMemoryPause(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	cc, sc := dial(t, &Transport{}, nil)
+	defer cc.Close()
+
+	p, ok := sc.(interface {
+		Pause()
+		Resume()
+	})
+	must.True(ok)
+
+	p.Pause()
+
+	written := make(chan error, 1)
+	go func() {
+		written <- write(t, sc, frame.String, packet.MESSAGE, []byte("resumed"))
+	}()
+
+	select {
+	case <-written:
+		must.FailNow("written while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	p.Resume()
+	must.NoError(<-written)
+	_, _, b := read(t, cc)
+	should.Equal("resumed", string(b))
+
+	// the writers of a paused conn retry with the conn it's upgraded to.
+	p.Pause()
+	go func() {
+		written <- write(t, sc, frame.String, packet.MESSAGE, nil)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	must.NoError(sc.Close())
+
+	err := <-written
+	should.True(err.(payload.Error).Temporary())
+}
@@ -0,0 +1,129 @@
+// Package memory is the engine.io transport of the connections within a
+// process, without HTTP, like the ones of tests, or of a server embedded in
+// the process of its clients:
+//
+//	t := &memory.Transport{}
+//	srv := socketio.NewServer(&engineio.Options{
+//		Transports: []transport.Transport{t},
+//	})
+//	t.Handler = srv
+//
+//	client, err := socketio.NewClient("http://embedded", &socketio.ClientOptions{
+//		Transports:       []string{t.Name()},
+//		CustomTransports: []transport.Transport{t},
+//		EIO:              3,
+//	})
+//
+// Its connections are a reference of the transport.Conn contract: they keep
+// the frame and packet types, follow the deadlines, and implement the Pauser
+// of the sessions upgraded from them.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/engineio/transport/utils"
+)
+
+var errNotDialed = errors.New("request not dialed with the memory transport")
+
+// Transport is the in-memory transport, whose connections are pipes within
+// the process. Dial serves Handler, like an engine.io or socket.io server,
+// with a request carrying the server side of the pipe, which Accept gives to
+// the server. No HTTP is sent.
+type Transport struct {
+	// Handler is the server of the dialed connections.
+	Handler http.Handler
+	// Clock is the clock of the deadlines of the connections, utils.RealClock
+	// by default.
+	Clock utils.Clock
+	// Alias is the name of the transport instead of memory, to tell memory
+	// transports apart, like the ones of an upgrade in tests.
+	Alias string
+}
+
+type connKey struct{}
+
+// Name is the name of memory transport.
+func (t *Transport) Name() string {
+	if t.Alias != "" {
+		return t.Alias
+	}
+	return "memory"
+}
+
+func (t *Transport) clock() utils.Clock {
+	if t.Clock != nil {
+		return t.Clock
+	}
+	return utils.RealClock
+}
+
+// Accept accepts the server side of a connection dialed by Dial.
+func (t *Transport) Accept(_ http.ResponseWriter, r *http.Request) (transport.Conn, error) {
+	c, ok := r.Context().Value(connKey{}).(*conn)
+	if !ok {
+		return nil, errNotDialed
+	}
+
+	return c, nil
+}
+
+// Dial connects to Handler, returning the client side of the connection.
+func (t *Transport) Dial(u *url.URL, requestHeader http.Header) (transport.Conn, error) {
+	if t.Handler == nil {
+		return nil, errors.New("memory transport without handler")
+	}
+
+	query := u.Query()
+	query.Set("transport", t.Name())
+	u.RawQuery = query.Encode()
+
+	client, server := pipe(*u, requestHeader, t.clock())
+
+	ctx := context.WithValue(context.Background(), connKey{}, server)
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	r.Header = requestHeader.Clone()
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+	r.RemoteAddr = client.LocalAddr().String()
+
+	w := &responseWriter{header: http.Header{}, status: http.StatusOK}
+	t.Handler.ServeHTTP(w, r)
+
+	if w.status != http.StatusOK {
+		_ = client.Close()
+		return nil, fmt.Errorf("memory dial: %d %s", w.status, strings.TrimSpace(w.body.String()))
+	}
+
+	return client, nil
+}
+
+// responseWriter keeps the response of the handler to a dial.
+type responseWriter struct {
+	header http.Header
+	status int
+	body   strings.Builder
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.status = status
+}
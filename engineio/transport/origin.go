@@ -0,0 +1,21 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+)
+
+type originAllowedKey struct{}
+
+// AllowOrigin returns r marked with its origin allowed by the CORS of the
+// server, for the transports not to check it again, nor to set their own CORS
+// headers.
+func AllowOrigin(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), originAllowedKey{}, true))
+}
+
+// OriginAllowed tells if the origin of r is allowed by AllowOrigin.
+func OriginAllowed(r *http.Request) bool {
+	allowed, _ := r.Context().Value(originAllowedKey{}).(bool)
+	return allowed
+}
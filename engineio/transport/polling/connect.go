@@ -2,6 +2,7 @@ package polling
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -9,8 +10,11 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/googollee/go-socket.io/engineio/frame"
 	"github.com/googollee/go-socket.io/engineio/packet"
 	"github.com/googollee/go-socket.io/engineio/payload"
 	"github.com/googollee/go-socket.io/engineio/transport"
@@ -24,6 +28,93 @@ type clientConn struct {
 	httpClient   *http.Client
 	request      http.Request
 	remoteHeader atomic.Value
+
+	// posting is the post of the last frame written, which Flush waits for.
+	postLocker sync.Mutex
+	posting    *post
+
+	handshakeTimeout time.Duration
+}
+
+// post is the post of a frame, with its result once done is closed.
+type post struct {
+	done chan struct{}
+	err  error
+}
+
+func (p *post) finish(err error) {
+	select {
+	case <-p.done:
+	default:
+		p.err = err
+		close(p.done)
+	}
+}
+
+// NextWriter returns a writer of the next frame. The frame is posted once the
+// writer is closed, without waiting for the post.
+func (c *clientConn) NextWriter(ft frame.Type, pt packet.Type) (io.WriteCloser, error) {
+	w, err := c.Payload.NextWriter(ft, pt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &postWriter{WriteCloser: w, conn: c}, nil
+}
+
+// Flush waits for the frames written to be posted. Once it returns, they have
+// been received by the server, which is needed to upgrade the connection.
+func (c *clientConn) Flush() error {
+	c.postLocker.Lock()
+	p := c.posting
+	c.postLocker.Unlock()
+
+	if p == nil {
+		return nil
+	}
+
+	<-p.done
+	return p.err
+}
+
+// pending gives the post of the frame flushed out, if it isn't done.
+func (c *clientConn) pending() *post {
+	c.postLocker.Lock()
+	defer c.postLocker.Unlock()
+
+	if c.posting == nil {
+		return nil
+	}
+
+	select {
+	case <-c.posting.done:
+		return nil
+	default:
+		return c.posting
+	}
+}
+
+type postWriter struct {
+	io.WriteCloser
+
+	conn *clientConn
+}
+
+func (w *postWriter) Close() error {
+	// the frame is flushed out once the writer is closed, so its post is
+	// set before.
+	p := &post{done: make(chan struct{})}
+
+	w.conn.postLocker.Lock()
+	w.conn.posting = p
+	w.conn.postLocker.Unlock()
+
+	if err := w.WriteCloser.Close(); err != nil {
+		p.finish(err)
+		return err
+	}
+
+	return nil
 }
 
 func (c *clientConn) Open() (transport.ConnParameters, error) {
@@ -107,29 +198,35 @@ func (c *clientConn) servePost() {
 		buf.Reset()
 
 		if err := c.Payload.FlushOut(&buf); err != nil {
+			if p := c.pending(); p != nil {
+				p.finish(err)
+			}
+
 			return
 		}
+		// the frame flushed out, or none with a noop.
+		p := c.pending()
+
 		query.Set("t", utils.Timestamp())
 		req.URL.RawQuery = query.Encode()
+		// the cookies of the jar are added to the header of the request.
+		req.Header = c.request.Header.Clone()
 
 		resp, err := c.httpClient.Do(&req)
-		if err != nil {
-			if err = c.Payload.Store("post", err); err != nil {
-				logger.Error("store post:", err)
-			}
+		if err == nil {
+			discardBody(resp.Body)
 
-			if err = c.Close(); err != nil {
-				logger.Error("close client connect:", err)
+			if resp.StatusCode != http.StatusOK {
+				err = fmt.Errorf("invalid response: %s(%d)", resp.Status, resp.StatusCode)
 			}
-
-			return
 		}
 
-		discardBody(resp.Body)
+		if p != nil {
+			p.finish(err)
+		}
 
-		if resp.StatusCode != http.StatusOK {
-			err = c.Payload.Store("post", fmt.Errorf("invalid response: %s(%d)", resp.Status, resp.StatusCode))
-			if err != nil {
+		if err != nil {
+			if err = c.Payload.Store("post", err); err != nil {
 				logger.Error("store post:", err)
 			}
 
@@ -154,6 +251,14 @@ func (c *clientConn) getOpen() {
 
 	query.Set("t", utils.Timestamp())
 	req.URL.RawQuery = query.Encode()
+	req.Header = c.request.Header.Clone()
+
+	if c.handshakeTimeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), c.handshakeTimeout)
+		defer cancel()
+
+		req = *req.WithContext(ctx)
+	}
 
 	resp, err := c.httpClient.Do(&req)
 	if err != nil {
@@ -217,6 +322,8 @@ func (c *clientConn) serveGet() {
 	for {
 		query.Set("t", utils.Timestamp())
 		req.URL.RawQuery = query.Encode()
+		// the cookies of the jar are added to the header of the request.
+		req.Header = c.request.Header.Clone()
 
 		resp, err := c.httpClient.Do(&req)
 		if err != nil {
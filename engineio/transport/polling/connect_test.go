@@ -96,3 +96,79 @@ func TestDialOpen(t *testing.T) {
 	must.NoError(err)
 	should.Nil(w.Close())
 }
+
+func TestDialFlush(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	cp := transport.ConnParameters{
+		PingInterval: time.Second,
+		PingTimeout:  time.Minute,
+		SID:          "abcdefg",
+	}
+
+	posting := make(chan struct{})
+	release := make(chan struct{})
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		if r.URL.Query().Get("sid") == "" {
+			buf := bytes.NewBuffer(nil)
+			_, err := cp.WriteTo(buf)
+			should.NoError(err)
+
+			_, _ = fmt.Fprintf(w, "%d:0%s", buf.Len()+1, buf.Bytes())
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			close(posting)
+			<-release
+		}
+	}
+
+	httpSvr := httptest.NewServer(http.HandlerFunc(handler))
+	defer httpSvr.Close()
+
+	u, err := url.Parse(httpSvr.URL)
+	must.NoError(err)
+
+	query := u.Query()
+	query.Set("b64", "1")
+	u.RawQuery = query.Encode()
+
+	cc, err := dial(nil, u, nil)
+	must.NoError(err)
+
+	defer func() {
+		must.NoError(cc.Close())
+	}()
+
+	_, err = cc.Open()
+	must.NoError(err)
+
+	w, err := cc.NextWriter(frame.String, packet.MESSAGE)
+	must.NoError(err)
+
+	_, err = w.Write([]byte("hello"))
+	must.NoError(err)
+
+	// the writer doesn't wait for the post of its frame.
+	must.NoError(w.Close())
+	<-posting
+
+	flushed := make(chan error)
+	go func() {
+		flushed <- cc.Flush()
+	}()
+
+	select {
+	case <-flushed:
+		must.FailNow("flushed before the post")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	should.NoError(<-flushed)
+}
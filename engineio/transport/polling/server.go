@@ -2,14 +2,17 @@ package polling
 
 import (
 	"bytes"
-	"fmt"
+	"compress/flate"
+	"compress/gzip"
 	"html/template"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"strings"
 
 	"github.com/googollee/go-socket.io/engineio/payload"
+	"github.com/googollee/go-socket.io/engineio/transport"
 	"github.com/googollee/go-socket.io/logger"
 )
 
@@ -66,6 +69,11 @@ func (c *serverConn) SetHeaders(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("X-XSS-Protection", "0")
 	}
 
+	// the CORS headers are set by the server once it allowed the origin.
+	if transport.OriginAllowed(r) {
+		return
+	}
+
 	// just in case the default behaviour gets changed and it has to handle an origin check
 	checkOrigin := Default.CheckOrigin
 	if c.transport.CheckOrigin != nil {
@@ -118,8 +126,21 @@ func (c *serverConn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
 		}
 
-		if err := c.Payload.FlushOut(w); err != nil {
+		if c.transport.CompressionThreshold <= 0 {
+			if err := c.Payload.FlushOut(w); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		buf := bytes.NewBuffer(nil)
+		if err := c.Payload.FlushOut(buf); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := writeCompressed(w, r, buf.Bytes(), c.transport.CompressionThreshold); err != nil {
+			logger.FromContext(r.Context()).Error("polling get write", logger.Err(err))
 		}
 
 	case http.MethodPost:
@@ -128,24 +149,53 @@ func (c *serverConn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		mime := r.Header.Get("Content-Type")
 		isSupportBinary, err := mimeIsSupportBinary(mime)
 		if err != nil {
-			logger.Error("Polling Transport MethodPost mimeIsSupportBinary", err)
+			logger.FromContext(r.Context()).Error("polling post mimeIsSupportBinary", logger.Err(err))
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
 		if err := c.Payload.FeedIn(r.Body, isSupportBinary); err != nil {
-			logger.Error("Polling Transport MethodPost FeedIn", err)
+			logger.FromContext(r.Context()).Error("polling post FeedIn", logger.Err(err))
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
 		_, err = w.Write([]byte("ok"))
 		if err != nil {
-			logger.Error("Polling Transport MethodPost Write", err)
-			fmt.Printf("ack post err=%s\n", err.Error())
+			logger.FromContext(r.Context()).Error("polling post Write", logger.Err(err))
 		}
 
 	default:
 		http.Error(w, "invalid method", http.StatusBadRequest)
 	}
 }
+
+// writeCompressed writes data to w, compressed with the encoding accepted by r
+// if data has at least threshold bytes.
+func writeCompressed(w http.ResponseWriter, r *http.Request, data []byte, threshold int) error {
+	encoding := ""
+	if len(data) >= threshold {
+		encoding = acceptedEncoding(r.Header.Get("Accept-Encoding"))
+	}
+
+	var cw io.WriteCloser
+	switch encoding {
+	case "gzip":
+		cw = gzip.NewWriter(w)
+	case "deflate":
+		cw, _ = flate.NewWriter(w, flate.DefaultCompression)
+	default:
+		_, err := w.Write(data)
+		return err
+	}
+
+	w.Header().Set("Content-Encoding", encoding)
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	if _, err := cw.Write(data); err != nil {
+		_ = cw.Close()
+		return err
+	}
+
+	return cw.Close()
+}
@@ -1,11 +1,14 @@
 package polling
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"html/template"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -107,3 +110,79 @@ func TestServerJSONP(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestServerCompression(t *testing.T) {
+	must := require.New(t)
+	should := assert.New(t)
+
+	pollingTransport := &Transport{CompressionThreshold: 16}
+	conn := make(chan transport.Conn, 1)
+
+	var scValue atomic.Value
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		c := scValue.Load()
+		if c == nil {
+			co, err := pollingTransport.Accept(w, r)
+			require.NoError(t, err)
+
+			scValue.Store(co)
+			c = co
+			conn <- co
+		}
+		c.(http.Handler).ServeHTTP(w, r)
+	}
+
+	httpSvr := httptest.NewServer(http.HandlerFunc(handler))
+	defer httpSvr.Close()
+
+	write := func(sc transport.Conn, data string) {
+		w, err := sc.NextWriter(frame.String, packet.MESSAGE)
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte(data))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+
+	get := func() (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodGet, httpSvr.URL+"?b64=1", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Encoding", "gzip")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		bs, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp, bs
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		sc := <-conn
+
+		write(sc, "hi")
+		write(sc, strings.Repeat("hello", 10))
+	}()
+
+	resp, bs := get()
+	should.Equal("", resp.Header.Get("Content-Encoding"))
+	should.Equal("3:4hi", string(bs))
+
+	resp, bs = get()
+	should.Equal("gzip", resp.Header.Get("Content-Encoding"))
+	should.Equal("Accept-Encoding", resp.Header.Get("Vary"))
+
+	gr, err := gzip.NewReader(bytes.NewReader(bs))
+	must.NoError(err)
+	bs, err = ioutil.ReadAll(gr)
+	must.NoError(err)
+	should.Equal("51:4"+strings.Repeat("hello", 10), string(bs))
+
+	wg.Wait()
+}
@@ -13,6 +13,15 @@ import (
 type Transport struct {
 	Client      *http.Client
 	CheckOrigin func(r *http.Request) bool
+
+	// HandshakeTimeout is the timeout of the open request when dialing. The
+	// timeout of Client applies if it's 0.
+	HandshakeTimeout time.Duration
+
+	// CompressionThreshold is the size in bytes from which responses are
+	// compressed with gzip or deflate, as accepted by the client. Responses
+	// aren't compressed if it's 0.
+	CompressionThreshold int
 }
 
 // Default is the default transport.
@@ -45,7 +54,13 @@ func (t *Transport) Dial(u *url.URL, requestHeader http.Header) (transport.Conn,
 		client = Default.Client
 	}
 
-	return dial(client, u, requestHeader)
+	conn, err := dial(client, u, requestHeader)
+	if err != nil {
+		return nil, err
+	}
+	conn.handshakeTimeout = t.HandshakeTimeout
+
+	return conn, nil
 }
 
 func dial(client *http.Client, url *url.URL, requestHeader http.Header) (*clientConn, error) {
@@ -59,15 +74,22 @@ func dial(client *http.Client, url *url.URL, requestHeader http.Header) (*client
 	for k, v := range requestHeader {
 		req.Header[k] = v
 	}
-	supportBinary := req.URL.Query().Get("b64") == ""
+	// the payloads of engine.io v4 are text only.
+	v4 := req.URL.Query().Get("EIO") == "4"
+	supportBinary := req.URL.Query().Get("b64") == "" && !v4
 	if supportBinary {
 		req.Header.Set("Content-Type", "application/octet-stream")
 	} else {
 		req.Header.Set("Content-Type", "text/plain;charset=UTF-8")
 	}
 
+	p := payload.New(supportBinary)
+	if v4 {
+		p = payload.NewV4()
+	}
+
 	return &clientConn{
-		Payload:    payload.New(supportBinary),
+		Payload:    p,
 		httpClient: client,
 		request:    *req,
 	}, nil
@@ -38,3 +38,29 @@ func mimeIsSupportBinary(m string) (bool, error) {
 
 	return false, errors.New("invalid content-type")
 }
+
+// acceptedEncoding gives the compression of responses accepted in the
+// Accept-Encoding header h, gzip before deflate, or "" if none is.
+func acceptedEncoding(h string) string {
+	deflate := false
+
+	for _, part := range strings.Split(h, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.ReplaceAll(strings.TrimSpace(params), " ", "") == "q=0" {
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip":
+			return "gzip"
+		case "deflate":
+			deflate = true
+		}
+	}
+
+	if deflate {
+		return "deflate"
+	}
+
+	return ""
+}
@@ -33,3 +33,23 @@ func TestNormalizeMime(t *testing.T) {
 		at.Equal(test.supportBinary, isSupportBinary)
 	}
 }
+
+func TestAcceptedEncoding(t *testing.T) {
+	at := assert.New(t)
+
+	tests := []struct {
+		header   string
+		encoding string
+	}{
+		{"", ""},
+		{"br", ""},
+		{"gzip", "gzip"},
+		{"deflate, gzip;q=1.0", "gzip"},
+		{"deflate, gzip;q=0", "deflate"},
+		{"DEFLATE", "deflate"},
+	}
+
+	for _, test := range tests {
+		at.Equal(test.encoding, acceptedEncoding(test.header), test.header)
+	}
+}
@@ -21,6 +21,13 @@ type FrameWriter interface {
 	NextWriter(ft frame.Type, pt packet.Type) (io.WriteCloser, error)
 }
 
+// Compressor is implemented by the frame writers of transports which compress
+// frames, to send a frame uncompressed. It must be called before the writer is
+// closed.
+type Compressor interface {
+	SetCompress(compress bool)
+}
+
 // Conn is a transport connection.
 type Conn interface {
 	FrameReader
@@ -8,12 +8,28 @@ type clock interface {
 	Now() time.Time
 }
 
+// Clock tells the time, and waits for it. The transports whose deadlines
+// follow a Clock can be driven by a fake one in tests.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine once d elapses, unless stop is
+	// called before, like time.AfterFunc.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// RealClock is the Clock of the time package.
+var RealClock Clock = timeClock{}
+
 type timeClock struct{}
 
 func (timeClock) Now() time.Time {
 	return time.Now()
 }
 
+func (timeClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
 // Timestamp returns a string based on different nano time.
 func Timestamp() string {
 	return TimestampFromClock(timeClock{})
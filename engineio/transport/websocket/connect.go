@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
@@ -27,8 +28,8 @@ type conn struct {
 	closeOnce sync.Once
 }
 
-func newConn(ws *websocket.Conn, url url.URL, header http.Header) *conn {
-	w := newWrapper(ws)
+func newConn(ws *websocket.Conn, url url.URL, header http.Header, c *compression, l *slog.Logger) *conn {
+	w := newWrapper(ws, c, l)
 	closed := make(chan struct{})
 
 	return &conn{
@@ -1,16 +1,21 @@
 package websocket
 
 import (
+	"compress/flate"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/googollee/go-socket.io/engineio/frame"
+	"github.com/googollee/go-socket.io/engineio/packet"
 	"github.com/googollee/go-socket.io/engineio/transport"
 )
 
@@ -61,3 +66,75 @@ func TestWebsocketSetReadDeadline(t *testing.T) {
 	at.True(ok)
 	at.True(op.Timeout())
 }
+
+func TestWebsocketCompression(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	tran := &Transport{
+		EnableCompression:    true,
+		CompressionLevel:     flate.BestSpeed,
+		CompressionThreshold: 16,
+	}
+	conn := make(chan transport.Conn, 1)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		c, err := tran.Accept(w, r)
+		require.NoError(t, err)
+
+		conn <- c
+	}
+	httpSvr := httptest.NewServer(http.HandlerFunc(handler))
+	defer httpSvr.Close()
+
+	u, err := url.Parse(httpSvr.URL)
+	must.NoError(err)
+
+	cc, err := tran.Dial(u, make(http.Header))
+	must.NoError(err)
+	defer func() {
+		should.NoError(cc.Close())
+	}()
+
+	sc := <-conn
+	defer func() {
+		should.NoError(sc.Close())
+	}()
+
+	messages := []struct {
+		data     string
+		compress bool
+	}{
+		{"hi", true},
+		{strings.Repeat("hello", 10), true},
+		{strings.Repeat("world", 10), false},
+	}
+
+	go func() {
+		for _, msg := range messages {
+			w, err := sc.NextWriter(frame.String, packet.MESSAGE)
+			require.NoError(t, err)
+
+			c, ok := w.(transport.Compressor)
+			require.True(t, ok)
+			c.SetCompress(msg.compress)
+
+			_, err = w.Write([]byte(msg.data))
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+		}
+	}()
+
+	for _, msg := range messages {
+		ft, pt, r, err := cc.NextReader()
+		must.NoError(err)
+
+		should.Equal(frame.String, ft)
+		should.Equal(packet.MESSAGE, pt)
+
+		b, err := ioutil.ReadAll(r)
+		must.NoError(err)
+		must.NoError(r.Close())
+
+		should.Equal(msg.data, string(b))
+	}
+}
@@ -9,8 +9,10 @@ import (
 
 	"github.com/gorilla/websocket"
 
+	"github.com/googollee/go-socket.io/engineio/packet"
 	"github.com/googollee/go-socket.io/engineio/transport"
 	"github.com/googollee/go-socket.io/engineio/transport/utils"
+	"github.com/googollee/go-socket.io/logger"
 )
 
 // DialError is the error when dialing to a server. It saves Response from
@@ -33,6 +35,18 @@ type Transport struct {
 	Proxy       func(*http.Request) (*url.URL, error)
 	NetDial     func(network, addr string) (net.Conn, error)
 	CheckOrigin func(r *http.Request) bool
+
+	// Jar sends and keeps the cookies of the server when dialing.
+	Jar http.CookieJar
+
+	// EnableCompression negotiates permessage-deflate with the peer.
+	EnableCompression bool
+	// CompressionLevel is the flate level of compressed messages, see
+	// compress/flate. The default level is used if it's 0.
+	CompressionLevel int
+	// CompressionThreshold is the size in bytes from which messages are
+	// compressed. All messages are compressed if it's 0.
+	CompressionThreshold int
 }
 
 // Default is default transport.
@@ -53,6 +67,9 @@ func (t *Transport) Dial(u *url.URL, requestHeader http.Header) (transport.Conn,
 		TLSClientConfig:  t.TLSClientConfig,
 		HandshakeTimeout: t.HandshakeTimeout,
 		Subprotocols:     t.Subprotocols,
+		Jar:              t.Jar,
+
+		EnableCompression: t.EnableCompression,
 	}
 
 	switch u.Scheme {
@@ -75,7 +92,15 @@ func (t *Transport) Dial(u *url.URL, requestHeader http.Header) (transport.Conn,
 		}
 	}
 
-	return newConn(c, *u, resp.Header), nil
+	conn := newConn(c, *u, resp.Header, t.compression(c), logger.Log)
+
+	// the binary frames of engine.io v4 are messages without packet type.
+	if query.Get("EIO") == "4" {
+		conn.FrameReader = packet.NewDecoderV4(conn.ws)
+		conn.FrameWriter = packet.NewEncoderV4(conn.ws)
+	}
+
+	return conn, nil
 }
 
 // Accept accepts a http request and create Conn.
@@ -84,11 +109,32 @@ func (t *Transport) Accept(w http.ResponseWriter, r *http.Request) (transport.Co
 		ReadBufferSize:  t.ReadBufferSize,
 		WriteBufferSize: t.WriteBufferSize,
 		CheckOrigin:     t.CheckOrigin,
+
+		EnableCompression: t.EnableCompression,
+	}
+	// the origin allowed by the CORS of the server isn't checked again.
+	if transport.OriginAllowed(r) {
+		upgrader.CheckOrigin = func(*http.Request) bool { return true }
 	}
+
 	c, err := upgrader.Upgrade(w, r, w.Header())
 	if err != nil {
 		return nil, err
 	}
 
-	return newConn(c, *r.URL, r.Header), nil
+	return newConn(c, *r.URL, r.Header, t.compression(c), logger.FromContext(r.Context())), nil
+}
+
+// compression gives the compression of c, nil if it isn't enabled. Writes
+// aren't compressed if the peer didn't negotiate permessage-deflate.
+func (t *Transport) compression(c *websocket.Conn) *compression {
+	if !t.EnableCompression {
+		return nil
+	}
+
+	if t.CompressionLevel != 0 {
+		_ = c.SetCompressionLevel(t.CompressionLevel)
+	}
+
+	return &compression{threshold: t.CompressionThreshold}
 }
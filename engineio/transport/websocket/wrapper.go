@@ -1,13 +1,13 @@
 package websocket
 
 import (
-	"fmt"
+	"bytes"
 	"io"
 	"io/ioutil"
+	"log/slog"
 	"sync"
 	"time"
 
-	"github.com/googollee/go-socket.io/logger"
 	"github.com/gorilla/websocket"
 
 	"github.com/googollee/go-socket.io/engineio/frame"
@@ -19,13 +19,27 @@ type wrapper struct {
 
 	writeLocker *sync.Mutex
 	readLocker  *sync.Mutex
+
+	// compression is the compression of messages, nil if permessage-deflate
+	// isn't enabled.
+	compression *compression
+
+	// logger logs the readers and writers not closed.
+	logger *slog.Logger
+}
+
+// compression is the negotiated permessage-deflate of a connection.
+type compression struct {
+	threshold int
 }
 
-func newWrapper(conn *websocket.Conn) wrapper {
+func newWrapper(conn *websocket.Conn, c *compression, l *slog.Logger) wrapper {
 	return wrapper{
 		Conn:        conn,
 		writeLocker: new(sync.Mutex),
 		readLocker:  new(sync.Mutex),
+		compression: c,
+		logger:      l,
 	}
 }
 
@@ -41,9 +55,9 @@ func (w wrapper) NextReader() (frame.Type, io.ReadCloser, error) {
 
 	switch typ {
 	case websocket.TextMessage:
-		return frame.String, newRcWrapper(w.readLocker, r), nil
+		return frame.String, newRcWrapper(w.readLocker, r, w.logger), nil
 	case websocket.BinaryMessage:
-		return frame.Binary, newRcWrapper(w.readLocker, r), nil
+		return frame.Binary, newRcWrapper(w.readLocker, r, w.logger), nil
 	}
 
 	return 0, nil, transport.ErrInvalidFrame
@@ -56,7 +70,7 @@ type rcWrapper struct {
 	l        *sync.Mutex
 }
 
-func newRcWrapper(l *sync.Mutex, r io.Reader) rcWrapper {
+func newRcWrapper(l *sync.Mutex, r io.Reader, log *slog.Logger) rcWrapper {
 	timer := time.NewTimer(30 * time.Second)
 	q := make(chan struct{})
 
@@ -64,7 +78,7 @@ func newRcWrapper(l *sync.Mutex, r io.Reader) rcWrapper {
 		select {
 		case <-q:
 		case <-timer.C:
-			logger.Error("Did you forget to Close() the ReadCloser from NextReader?", fmt.Errorf("ConnectionNotClosed"))
+			log.Error("Did you forget to Close() the ReadCloser from NextReader?")
 		}
 	}()
 
@@ -84,8 +98,12 @@ func (r rcWrapper) Close() error {
 	r.nagTimer.Stop()
 	close(r.quitNag)
 
-	// Attempt to drain the Reader.
+	// Attempt to drain the Reader. A compressed message which is read to
+	// the end reports io.ErrClosedPipe.
 	_, err := io.Copy(ioutil.Discard, r)
+	if err == io.ErrClosedPipe {
+		return nil
+	}
 
 	return err
 }
@@ -103,6 +121,16 @@ func (w wrapper) NextWriter(FType frame.Type) (io.WriteCloser, error) {
 	}
 
 	w.writeLocker.Lock()
+
+	if w.compression != nil {
+		return newWcWrapper(w.logger, w.writeLocker, &compressWriter{
+			conn:      w.Conn,
+			typ:       t,
+			threshold: w.compression.threshold,
+			compress:  true,
+		}), nil
+	}
+
 	writer, err := w.Conn.NextWriter(t)
 	// The wrapper remains locked until the returned WriteCloser is Closed.
 	if err != nil {
@@ -110,7 +138,7 @@ func (w wrapper) NextWriter(FType frame.Type) (io.WriteCloser, error) {
 		return nil, err
 	}
 
-	return newWcWrapper(w.writeLocker, writer), nil
+	return newWcWrapper(w.logger, w.writeLocker, writer), nil
 }
 
 type wcWrapper struct {
@@ -121,7 +149,7 @@ type wcWrapper struct {
 	quitNag chan struct{}
 }
 
-func newWcWrapper(l *sync.Mutex, w io.WriteCloser) wcWrapper {
+func newWcWrapper(log *slog.Logger, l *sync.Mutex, w io.WriteCloser) wcWrapper {
 	timer := time.NewTimer(30 * time.Second)
 	chQuit := make(chan struct{})
 
@@ -129,7 +157,7 @@ func newWcWrapper(l *sync.Mutex, w io.WriteCloser) wcWrapper {
 		select {
 		case <-chQuit:
 		case <-timer.C:
-			logger.Error("Did you forget to Close() the WriteCloser from NextWriter?", fmt.Errorf("ConnectionNotClosed"))
+			log.Error("Did you forget to Close() the WriteCloser from NextWriter?")
 		}
 	}()
 
@@ -141,6 +169,13 @@ func newWcWrapper(l *sync.Mutex, w io.WriteCloser) wcWrapper {
 	}
 }
 
+// SetCompress passes compress to the message writer, if it compresses messages.
+func (w wcWrapper) SetCompress(compress bool) {
+	if c, ok := w.WriteCloser.(transport.Compressor); ok {
+		c.SetCompress(compress)
+	}
+}
+
 func (w wcWrapper) Close() error {
 	// Stop the nagger.
 	w.nagTimer.Stop()
@@ -149,3 +184,28 @@ func (w wcWrapper) Close() error {
 	defer w.l.Unlock()
 	return w.WriteCloser.Close()
 }
+
+// compressWriter buffers a message, to decide when it's closed whether the
+// message is compressed.
+type compressWriter struct {
+	conn      *websocket.Conn
+	typ       int
+	threshold int
+	compress  bool
+
+	buf bytes.Buffer
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *compressWriter) SetCompress(compress bool) {
+	w.compress = compress
+}
+
+func (w *compressWriter) Close() error {
+	w.conn.EnableWriteCompression(w.compress && w.buf.Len() >= w.threshold)
+
+	return w.conn.WriteMessage(w.typ, w.buf.Bytes())
+}
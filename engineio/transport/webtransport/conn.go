@@ -0,0 +1,146 @@
+package webtransport
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	wt "github.com/quic-go/webtransport-go"
+
+	"github.com/googollee/go-socket.io/engineio/frame"
+	"github.com/googollee/go-socket.io/engineio/packet"
+	"github.com/googollee/go-socket.io/engineio/transport"
+)
+
+// closeTimeout is the time for the peer to read the frames written before a
+// conn is closed.
+const closeTimeout = 5 * time.Second
+
+// conn implements base.Conn
+type conn struct {
+	transport.FrameReader
+	transport.FrameWriter
+
+	session *wt.Session
+	stream  *stream
+
+	url          url.URL
+	remoteHeader http.Header
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newConn(session *wt.Session, str wt.Stream, url url.URL, header http.Header) *conn {
+	s := newStream(str)
+
+	return &conn{
+		session:      session,
+		stream:       s,
+		url:          url,
+		remoteHeader: header,
+		closed:       make(chan struct{}),
+		FrameReader:  packet.NewDecoder(s),
+		FrameWriter:  packet.NewEncoder(s),
+	}
+}
+
+// open writes the open packet starting the stream of a client, with the sid
+// of the session it's upgrading.
+func (c *conn) open(sid string) error {
+	w, err := c.NextWriter(frame.String, packet.OPEN)
+	if err != nil {
+		return err
+	}
+
+	if sid != "" {
+		b, err := json.Marshal(map[string]string{"sid": sid})
+		if err != nil {
+			_ = w.Close()
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			_ = w.Close()
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+// accept reads the open packet of the stream of a client before deadline.
+// The session is found by the sid of the request, so its data is discarded.
+func (c *conn) accept(deadline time.Time) error {
+	if err := c.SetReadDeadline(deadline); err != nil {
+		return err
+	}
+
+	_, pt, r, err := c.NextReader()
+	if err != nil {
+		return err
+	}
+	if err := r.Close(); err != nil {
+		return err
+	}
+
+	if pt != packet.OPEN {
+		return errNotOpened
+	}
+
+	return c.SetReadDeadline(time.Time{})
+}
+
+func (c *conn) URL() url.URL {
+	return c.url
+}
+
+func (c *conn) RemoteHeader() http.Header {
+	return c.remoteHeader
+}
+
+func (c *conn) LocalAddr() net.Addr {
+	return c.session.LocalAddr()
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.session.RemoteAddr()
+}
+
+func (c *conn) SetReadDeadline(t time.Time) error {
+	return c.stream.SetReadDeadline(t)
+}
+
+func (c *conn) SetWriteDeadline(t time.Time) error {
+	return c.stream.SetWriteDeadline(t)
+}
+
+func (c *conn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	<-c.closed
+}
+
+// Close closes the stream, whose frames written are still sent, then the
+// session once the peer closes it too, or after closeTimeout.
+func (c *conn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+
+		c.stream.CancelRead(0)
+		err = c.stream.Close()
+
+		go func() {
+			timer := time.NewTimer(closeTimeout)
+			defer timer.Stop()
+
+			select {
+			case <-c.session.Context().Done():
+			case <-timer.C:
+			}
+			_ = c.session.CloseWithError(0, "")
+		}()
+	})
+	return err
+}
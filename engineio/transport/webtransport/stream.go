@@ -0,0 +1,174 @@
+package webtransport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	wt "github.com/quic-go/webtransport-go"
+
+	"github.com/googollee/go-socket.io/engineio/frame"
+	"github.com/googollee/go-socket.io/engineio/transport"
+)
+
+// binaryFlag is the bit of the first byte of a frame header telling the frame
+// is binary.
+const binaryFlag = 0x80
+
+// ErrTimeout is the error of the reads and writes whose deadline passed.
+var ErrTimeout error = timeoutError{}
+
+// timeoutError is a timeout, unlike the ones of QUIC streams not temporary,
+// for the session not to retry.
+type timeoutError struct{}
+
+func (timeoutError) Error() string {
+	return "i/o timeout"
+}
+
+func (timeoutError) Timeout() bool {
+	return true
+}
+
+func (timeoutError) Temporary() bool {
+	return false
+}
+
+// streamError gives ErrTimeout for the timeouts of the stream.
+func streamError(err error) error {
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return ErrTimeout
+	}
+	return err
+}
+
+// stream frames the packets on a WebTransport stream like engine.io-parser:
+// each frame is preceded by its length, in a byte if it's below 126, else in
+// the 2 bytes following 126, or the 8 bytes following 127. The first bit of
+// the header tells if the frame is binary.
+type stream struct {
+	wt.Stream
+
+	readLocker  sync.Mutex
+	writeLocker sync.Mutex
+}
+
+func newStream(str wt.Stream) *stream {
+	return &stream{Stream: str}
+}
+
+// NextReader reads the header of the next frame. The stream remains locked
+// until the returned ReadCloser is closed.
+func (s *stream) NextReader() (frame.Type, io.ReadCloser, error) {
+	s.readLocker.Lock()
+
+	ft, n, err := s.readHeader()
+	if err != nil {
+		s.readLocker.Unlock()
+		return 0, nil, streamError(err)
+	}
+
+	return ft, &reader{
+		Reader: io.LimitReader(s.Stream, n),
+		unlock: s.readLocker.Unlock,
+	}, nil
+}
+
+func (s *stream) readHeader() (frame.Type, int64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(s.Stream, b[:1]); err != nil {
+		return 0, 0, err
+	}
+
+	ft := frame.String
+	if b[0]&binaryFlag != 0 {
+		ft = frame.Binary
+	}
+
+	n := uint64(b[0] &^ binaryFlag)
+	switch n {
+	case 126:
+		if _, err := io.ReadFull(s.Stream, b[:2]); err != nil {
+			return 0, 0, err
+		}
+		n = uint64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err := io.ReadFull(s.Stream, b[:]); err != nil {
+			return 0, 0, err
+		}
+		n = binary.BigEndian.Uint64(b[:])
+	}
+
+	if int64(n) < 0 {
+		return 0, 0, transport.ErrInvalidFrame
+	}
+
+	return ft, int64(n), nil
+}
+
+// NextWriter returns the writer of a frame, which is written once it's closed.
+func (s *stream) NextWriter(ft frame.Type) (io.WriteCloser, error) {
+	if ft != frame.String && ft != frame.Binary {
+		return nil, transport.ErrInvalidFrame
+	}
+
+	return &writer{stream: s, ft: ft}, nil
+}
+
+// reader reads a frame, and drains it once it's closed.
+type reader struct {
+	io.Reader
+
+	unlock func()
+	once   sync.Once
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	return n, streamError(err)
+}
+
+func (r *reader) Close() error {
+	var err error
+	r.once.Do(func() {
+		defer r.unlock()
+		_, err = io.Copy(io.Discard, r.Reader)
+	})
+	return streamError(err)
+}
+
+// writer buffers a frame, which is written with its header once it's closed.
+type writer struct {
+	bytes.Buffer
+
+	stream *stream
+	ft     frame.Type
+}
+
+func (w *writer) Close() error {
+	n := w.Len()
+
+	b := make([]byte, 0, 9+n)
+	switch {
+	case n < 126:
+		b = append(b, byte(n))
+	case n < 1<<16:
+		b = binary.BigEndian.AppendUint16(append(b, 126), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint64(append(b, 127), uint64(n))
+	}
+	if w.ft == frame.Binary {
+		b[0] |= binaryFlag
+	}
+	b = append(b, w.Bytes()...)
+
+	w.stream.writeLocker.Lock()
+	defer w.stream.writeLocker.Unlock()
+
+	_, err := w.stream.Write(b)
+	return streamError(err)
+}
@@ -0,0 +1,158 @@
+// Package webtransport is the engine.io transport over WebTransport, which
+// sends the packets of a connection on a bidirectional stream of an HTTP/3
+// session, over QUIC.
+//
+// HTTP/3 is served on UDP, so the engine.io server is served by the
+// webtransport.Server of the transport too, on the port of the HTTP server of
+// the other transports:
+//
+//	t := &webtransport.Transport{
+//		Server: &wt.Server{H3: http3.Server{Addr: ":443"}},
+//	}
+//	srv := engineio.NewServer(&engineio.Options{
+//		Transports: []transport.Transport{polling.Default, websocket.Default, t},
+//	})
+//	t.Server.H3.Handler = srv
+//	go t.Server.ListenAndServeTLS(certFile, keyFile)
+//
+// The transports are upgraded in the order of the options, so WebTransport is
+// the last of them: polling is upgraded to websocket, then to WebTransport,
+// for the clients supporting it.
+package webtransport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	wt "github.com/quic-go/webtransport-go"
+
+	"github.com/googollee/go-socket.io/engineio/packet"
+	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/engineio/transport/utils"
+)
+
+const defaultHandshakeTimeout = 10 * time.Second
+
+var (
+	errNoServer  = errors.New("webtransport transport without server")
+	errNotOpened = errors.New("webtransport stream not opened with an open packet")
+)
+
+var defaultDialer = &wt.Dialer{}
+
+// DialError is the error when dialing to a server. It saves Response from
+// server.
+type DialError struct {
+	Response *http.Response
+
+	error
+}
+
+// Transport is webtransport transport.
+type Transport struct {
+	// Server upgrades the requests accepted to WebTransport sessions. Its
+	// HTTP/3 server must serve the engine.io server.
+	Server *wt.Server
+	// Dialer dials the sessions of the clients, whose TLSClientConfig must
+	// trust the certificate of the server.
+	Dialer *wt.Dialer
+	// HandshakeTimeout is the time for the session to open its stream, 10
+	// seconds by default.
+	HandshakeTimeout time.Duration
+}
+
+// Name is the name of webtransport transport.
+func (t *Transport) Name() string {
+	return "webtransport"
+}
+
+func (t *Transport) dialer() *wt.Dialer {
+	if t.Dialer != nil {
+		return t.Dialer
+	}
+	return defaultDialer
+}
+
+func (t *Transport) handshakeTimeout() time.Duration {
+	if t.HandshakeTimeout > 0 {
+		return t.HandshakeTimeout
+	}
+	return defaultHandshakeTimeout
+}
+
+// Dial creates a new client connection. WebTransport is only served over
+// HTTPS, so the scheme of u is replaced by https.
+func (t *Transport) Dial(u *url.URL, requestHeader http.Header) (transport.Conn, error) {
+	u.Scheme = "https"
+
+	query := u.Query()
+	query.Set("transport", t.Name())
+	query.Set("t", utils.Timestamp())
+	u.RawQuery = query.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.handshakeTimeout())
+	defer cancel()
+
+	// the dialer sets its headers to the request header.
+	resp, session, err := t.dialer().Dial(ctx, u.String(), requestHeader.Clone())
+	if err != nil {
+		return nil, DialError{
+			error:    err,
+			Response: resp,
+		}
+	}
+
+	str, err := session.OpenStreamSync(ctx)
+	if err != nil {
+		_ = session.CloseWithError(0, "")
+		return nil, err
+	}
+
+	conn := newConn(session, str, *u, resp.Header)
+
+	// the binary frames of engine.io v4 are messages without packet type.
+	if query.Get("EIO") == "4" {
+		conn.FrameReader = packet.NewDecoderV4(conn.stream)
+		conn.FrameWriter = packet.NewEncoderV4(conn.stream)
+	}
+
+	if err := conn.open(query.Get("sid")); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Accept accepts a http request and create Conn.
+func (t *Transport) Accept(w http.ResponseWriter, r *http.Request) (transport.Conn, error) {
+	if t.Server == nil {
+		return nil, errNoServer
+	}
+
+	session, err := t.Server.Upgrade(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), t.handshakeTimeout())
+	defer cancel()
+
+	str, err := session.AcceptStream(ctx)
+	if err != nil {
+		_ = session.CloseWithError(0, "")
+		return nil, err
+	}
+
+	conn := newConn(session, str, *r.URL, r.Header)
+
+	if err := conn.accept(time.Now().Add(t.handshakeTimeout())); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,346 @@
+package webtransport
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	wt "github.com/quic-go/webtransport-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/googollee/go-socket.io/engineio"
+	"github.com/googollee/go-socket.io/engineio/frame"
+	"github.com/googollee/go-socket.io/engineio/packet"
+	"github.com/googollee/go-socket.io/engineio/payload"
+	"github.com/googollee/go-socket.io/engineio/session"
+	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/engineio/transport/polling"
+)
+
+var tests = []struct {
+	ft   frame.Type
+	pt   packet.Type
+	data []byte
+}{
+	{frame.String, packet.OPEN, []byte{}},
+	{frame.String, packet.MESSAGE, []byte("hello")},
+	{frame.Binary, packet.MESSAGE, []byte{1, 2, 3, 4}},
+	{frame.String, packet.MESSAGE, []byte(strings.Repeat("a", 1000))},
+	{frame.Binary, packet.MESSAGE, bytes.Repeat([]byte{5}, 70000)},
+}
+
+// selfSigned returns the TLS config of a server with a self-signed certificate
+// of 127.0.0.1, and the pool of the clients trusting it.
+func selfSigned(t *testing.T) (*tls.Config, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}, pool
+}
+
+// serve serves handler with the server of tr on pc, setting the dialer of tr
+// to trust it, and returns the URL of the server.
+func serve(t *testing.T, tr *Transport, pc net.PacketConn, handler http.Handler) string {
+	tlsConfig, pool := selfSigned(t)
+
+	tr.Server = &wt.Server{
+		H3: http3.Server{
+			TLSConfig: tlsConfig,
+			Handler:   handler,
+		},
+	}
+	tr.Dialer = &wt.Dialer{
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+	}
+
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		_ = tr.Server.Serve(pc)
+	}()
+
+	t.Cleanup(func() {
+		_ = tr.Dialer.Close()
+		_ = tr.Server.Close()
+		_ = pc.Close()
+		<-served
+	})
+
+	return fmt.Sprintf("https://%s/engine.io/", pc.LocalAddr())
+}
+
+func listen(t *testing.T, addr string) net.PacketConn {
+	pc, err := net.ListenPacket("udp", addr)
+	require.NoError(t, err)
+	return pc
+}
+
+// dial dials a conn of tr, and returns it with the conn accepted by the
+// server.
+func dial(t *testing.T, tr *Transport, query string, header http.Header) (transport.Conn, transport.Conn) {
+	accepted := make(chan transport.Conn, 1)
+	u := serve(t, tr, listen(t, "127.0.0.1:0"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Eio-Test", "server")
+		c, err := tr.Accept(w, r)
+		if !assert.NoError(t, err) {
+			return
+		}
+		accepted <- c
+	}))
+
+	du, err := url.Parse(u + query)
+	require.NoError(t, err)
+
+	cc, err := tr.Dial(du, header)
+	require.NoError(t, err)
+
+	select {
+	case sc := <-accepted:
+		return cc, sc
+	case <-time.After(5 * time.Second):
+		t.Fatal("conn not accepted")
+		return nil, nil
+	}
+}
+
+func write(t *testing.T, c transport.Conn, ft frame.Type, pt packet.Type, data []byte) {
+	w, err := c.NextWriter(ft, pt)
+	require.NoError(t, err)
+
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
+
+func read(t *testing.T, c transport.Conn) (frame.Type, packet.Type, []byte) {
+	ft, pt, r, err := c.NextReader()
+	require.NoError(t, err)
+
+	b, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	return ft, pt, b
+}
+
+func TestWebTransport(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	tr := &Transport{}
+	should.Equal("webtransport", tr.Name())
+
+	header := http.Header{}
+	header.Set("X-Eio-Test", "client")
+
+	cc, sc := dial(t, tr, "?EIO=3", header)
+	defer func() {
+		must.NoError(cc.Close())
+	}()
+	defer func() {
+		must.NoError(sc.Close())
+	}()
+
+	should.Equal("client", sc.RemoteHeader().Get("X-Eio-Test"))
+	should.Equal("server", cc.RemoteHeader().Get("X-Eio-Test"))
+	should.Empty(header.Get("Sec-Webtransport-Http3-Draft02"))
+
+	ccURL, scURL := cc.URL(), sc.URL()
+	should.Equal("webtransport", ccURL.Query().Get("transport"))
+	should.NotEmpty(ccURL.Query().Get("t"))
+	should.Equal(ccURL.RawQuery, scURL.RawQuery)
+	should.Equal(cc.LocalAddr().(*net.UDPAddr).Port, sc.RemoteAddr().(*net.UDPAddr).Port)
+	should.Equal(cc.RemoteAddr().String(), sc.LocalAddr().String())
+
+	for _, test := range tests {
+		write(t, cc, test.ft, test.pt, test.data)
+
+		ft, pt, b := read(t, sc)
+		should.Equal(test.ft, ft)
+		should.Equal(test.pt, pt)
+		should.Equal(test.data, b)
+
+		write(t, sc, test.ft, test.pt, test.data)
+
+		ft, pt, b = read(t, cc)
+		should.Equal(test.ft, ft)
+		should.Equal(test.pt, pt)
+		should.Equal(test.data, b)
+	}
+
+	_, err := cc.NextWriter(frame.Type(9), packet.MESSAGE)
+	should.Equal(transport.ErrInvalidFrame, err)
+}
+
+func TestWebTransportFraming(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	// the frames of engine.io v4 are the ones of engine.io-parser.
+	cc, sc := dial(t, &Transport{}, "?EIO=4", nil)
+	defer cc.Close()
+	defer sc.Close()
+
+	write(t, cc, frame.String, packet.MESSAGE, []byte("hello"))
+	write(t, cc, frame.Binary, packet.MESSAGE, []byte{1, 2, 3})
+	write(t, cc, frame.String, packet.MESSAGE, []byte(strings.Repeat("a", 199)))
+
+	b := make([]byte, 7+4+3+200)
+	_, err := io.ReadFull(sc.(*conn).stream, b)
+	must.NoError(err)
+	should.Equal(append([]byte{6}, "4hello"...), b[:7])
+	should.Equal([]byte{0x80 | 3, 1, 2, 3}, b[7:11])
+	should.Equal([]byte{126, 0, 200, '4'}, b[11:15])
+}
+
+func TestWebTransportDeadline(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	cc, sc := dial(t, &Transport{}, "?EIO=3", nil)
+	defer cc.Close()
+	defer sc.Close()
+
+	must.NoError(sc.SetReadDeadline(time.Now().Add(20 * time.Millisecond)))
+	_, _, _, err := sc.NextReader()
+	should.Equal(ErrTimeout, err)
+	should.False(err.(payload.Error).Temporary())
+}
+
+func TestWebTransportAcceptError(t *testing.T) {
+	should := assert.New(t)
+
+	tr := &Transport{}
+	_, err := tr.Accept(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	should.Equal(errNoServer, err)
+
+	tr.Server = &wt.Server{}
+	_, err = tr.Accept(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	should.Error(err)
+}
+
+func TestWebTransportUpgrade(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	wtTransport := &Transport{}
+	pollingTransport := &polling.Transport{}
+
+	svr := engineio.NewServer(&engineio.Options{
+		Transports: []transport.Transport{pollingTransport, wtTransport},
+	})
+	defer func() {
+		must.NoError(svr.Close())
+	}()
+
+	// polling is served over TCP, and WebTransport over UDP, on the same
+	// port.
+	httpSvr := httptest.NewUnstartedServer(svr)
+	pc := listen(t, httpSvr.Listener.Addr().String())
+	u := serve(t, wtTransport, pc, svr)
+
+	httpSvr.TLS = wtTransport.Server.H3.TLSConfig
+	httpSvr.StartTLS()
+	defer httpSvr.Close()
+
+	pollingTransport.Client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: wtTransport.Dialer.TLSClientConfig,
+		},
+	}
+
+	go func() {
+		conn, err := svr.Accept()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+
+		ft, r, err := conn.NextReader()
+		if !assert.NoError(t, err) {
+			return
+		}
+		b, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.NoError(t, r.Close())
+		assert.Equal(t, session.TEXT, ft)
+		assert.Equal(t, "hello", string(b))
+		assert.Equal(t, "webtransport", conn.Transport())
+
+		w, err := conn.NextWriter(session.BINARY)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = w.Write([]byte{1, 2, 3, 4})
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+	}()
+
+	dialer := engineio.Dialer{
+		Transports: []transport.Transport{pollingTransport, wtTransport},
+		Version:    3,
+	}
+
+	cnt, err := dialer.Dial(u, nil)
+	must.NoError(err)
+	defer cnt.Close()
+	should.Equal("polling", cnt.Handshake().Transport)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for cnt.Transport() != "webtransport" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	must.Equal("webtransport", cnt.Transport())
+
+	w, err := cnt.NextWriter(session.TEXT)
+	must.NoError(err)
+	_, err = w.Write([]byte("hello"))
+	must.NoError(err)
+	must.NoError(w.Close())
+
+	ft, r, err := cnt.NextReader()
+	must.NoError(err)
+	should.Equal(session.BINARY, ft)
+
+	b, err := ioutil.ReadAll(r)
+	must.NoError(err)
+	must.NoError(r.Close())
+	should.Equal([]byte{1, 2, 3, 4}, b)
+}
@@ -12,6 +12,13 @@ const (
 type funcHandler struct {
 	argTypes []reflect.Type
 	f        reflect.Value
+
+	// once tells if the event handler is removed once called.
+	once bool
+	// fail fails the ack of EmitWithAck with an error, nil for the other acks.
+	fail func(err error)
+	// rest tells if the args beyond argTypes are passed to the variadic f.
+	rest bool
 }
 
 func (h *funcHandler) Call(args []reflect.Value) (ret []reflect.Value, err error) {
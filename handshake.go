@@ -0,0 +1,54 @@
+package socketio
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/googollee/go-socket.io/engineio/session"
+)
+
+// Handshake is the details of the handshake of a connection, like
+// socket.handshake in socket.io.
+type Handshake struct {
+	// Time is when the engine.io session is created.
+	Time time.Time `json:"time"`
+	// Issued is Time in unix milliseconds.
+	Issued int64 `json:"issued"`
+	// Address is the remote address of the client.
+	Address string `json:"address"`
+	// Headers is the header of the request which opened the session.
+	Headers http.Header `json:"headers"`
+	// Query is the parsed query of URL.
+	Query url.Values `json:"query"`
+	// URL is the url of the request which opened the session.
+	URL string `json:"url"`
+	// XDomain reports whether the request is cross-origin.
+	XDomain bool `json:"xdomain"`
+	// Secure reports whether the session is opened over TLS.
+	Secure bool `json:"secure"`
+	// Transport is the engine.io transport at connect time.
+	Transport string `json:"transport"`
+	// Auth is the payload of the CONNECT packet of the namespace.
+	Auth map[string]interface{} `json:"auth"`
+	// PeerCertificates are the certificates presented by the client, which
+	// are only set with mutual TLS.
+	PeerCertificates []*x509.Certificate `json:"-"`
+}
+
+func newHandshake(h session.Handshake, auth map[string]interface{}) Handshake {
+	return Handshake{
+		Time:             h.Time,
+		Issued:           h.Time.UnixMilli(),
+		Address:          h.Address,
+		Headers:          h.Header,
+		Query:            h.Query,
+		URL:              h.URL.String(),
+		XDomain:          h.Header.Get("Origin") != "",
+		Secure:           h.Secure,
+		Transport:        h.Transport,
+		Auth:             auth,
+		PeerCertificates: h.PeerCertificates,
+	}
+}
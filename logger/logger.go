@@ -1,15 +1,54 @@
 package logger
 
 import (
-	"golang.org/x/exp/slog"
+	"context"
+	"log/slog"
 )
 
+// Attribute keys of log lines.
+const (
+	SIDKey       = "sid"
+	NamespaceKey = "namespace"
+	TransportKey = "transport"
+	EventKey     = "event"
+	ErrKey       = "err"
+)
+
+// Log is the default logger. It is used by servers without a logger, and by
+// code which has no logger of a server at hand, like the parsers.
 var Log *slog.Logger = slog.Default()
 
+type contextKey struct{}
+
+// Error logs msg with err at error level on the default logger.
 func Error(msg string, err error) {
-	Log.Error(msg, "err", err.Error())
+	Log.Error(msg, Err(err))
 }
 
+// Info logs msg with args at info level on the default logger.
 func Info(msg string, args ...interface{}) {
 	Log.Info(msg, args...)
 }
+
+// Err gives the attribute of err. err may be nil.
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.Any(ErrKey, nil)
+	}
+
+	return slog.String(ErrKey, err.Error())
+}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext gives the logger carried by ctx, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok && l != nil {
+		return l
+	}
+
+	return Log
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErr(t *testing.T) {
+	should := assert.New(t)
+
+	should.Equal(slog.String(ErrKey, "failed"), Err(errors.New("failed")))
+	should.NotPanics(func() {
+		Error("nil error", nil)
+	})
+	should.Equal(ErrKey, Err(nil).Key)
+}
+
+func TestContext(t *testing.T) {
+	should := assert.New(t)
+
+	should.Equal(Log, FromContext(context.Background()))
+
+	buf := bytes.NewBuffer(nil)
+	l := slog.New(slog.NewTextHandler(buf, nil))
+
+	FromContext(NewContext(context.Background(), l)).Info("message", SIDKey, "sid")
+	should.Contains(buf.String(), "msg=message sid=sid")
+}
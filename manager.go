@@ -0,0 +1,538 @@
+package socketio
+
+import (
+	"errors"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/googollee/go-socket.io/engineio"
+	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/metrics"
+	"github.com/googollee/go-socket.io/parser"
+)
+
+// Manager owns one engine.io connection to a socket.io server, multiplexing
+// the Sockets of its namespaces.
+type Manager struct {
+	url string
+
+	connMu   sync.RWMutex
+	conn     *conn
+	handlers *namespaceHandlers
+	parser   parser.Parser
+
+	socketsMu sync.Mutex
+	sockets   map[string]*Socket
+
+	opts         *ClientOptions
+	transports   []transport.Transport
+	tracer       *tracer
+	logger       *slog.Logger
+	reconnection *ReconnectionOptions
+	buffer       *sendBuffer
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewManager returns a manager of the server at addr, like
+// http://asd.com:8080, configured with opts. The path of addr is the
+// engine.io path, /socket.io/ by default.
+func NewManager(addr string, opts *ClientOptions) (*Manager, error) {
+	if addr == "" {
+		return nil, EmptyAddrErr
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/socket.io/"
+	}
+
+	return newManager(u, opts)
+}
+
+func newManager(u *url.URL, opts *ClientOptions) (*Manager, error) {
+	transports, err := opts.transports()
+	if err != nil {
+		return nil, err
+	}
+
+	query := u.Query()
+	for k, v := range opts.getQuery() {
+		query[k] = v
+	}
+	u.RawQuery = query.Encode()
+
+	m := &Manager{
+		url:        u.String(),
+		handlers:   newNamespaceHandlers(),
+		parser:     parser.JSON,
+		sockets:    make(map[string]*Socket),
+		opts:       opts,
+		transports: transports,
+		tracer:     newTracer(),
+		logger:     opts.getLogger(),
+		closed:     make(chan struct{}),
+	}
+
+	if bufferOpts := opts.getSendBuffer(); bufferOpts != nil {
+		if m.buffer, err = newSendBuffer(bufferOpts, m.logger); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Reconnection enables the reconnection with opts once the connection is
+// lost, until Close is called. The handlers of OnEvent of the connected
+// sockets get the "reconnect_attempt" and "reconnect" events with the attempt
+// number, and the "reconnect_failed" event once the attempts are exhausted. It
+// must be called before Connect.
+func (m *Manager) Reconnection(opts *ReconnectionOptions) {
+	if opts == nil {
+		opts = &ReconnectionOptions{}
+	}
+
+	m.reconnection = opts
+}
+
+// SetParser sets the parser of socket.io packets, parser.JSON by default. It
+// must be the parser of the server, and be called before Connect.
+func (m *Manager) SetParser(p parser.Parser) {
+	m.parser = p
+}
+
+// Tracing enables OpenTelemetry tracing of events and acks with opts. It must
+// be called before Connect.
+func (m *Manager) Tracing(opts *TracingOptions) {
+	m.tracer.configure(opts)
+}
+
+// Socket returns the socket of namespace, which shares the connection of the
+// manager. It isn't connected until its Connect is called.
+func (m *Manager) Socket(namespace string) *Socket {
+	namespace = fmtNS(namespace)
+
+	m.socketsMu.Lock()
+	defer m.socketsMu.Unlock()
+
+	if s, ok := m.sockets[namespace]; ok {
+		return s
+	}
+
+	s := &Socket{
+		manager:   m,
+		namespace: namespace,
+	}
+	m.sockets[namespace] = s
+
+	if m.getNamespace(namespace) == nil {
+		m.createNamespace(namespace)
+	}
+
+	return s
+}
+
+// Connect dials the server if the manager isn't connected, and connects the
+// sockets whose Connect was called.
+func (m *Manager) Connect() error {
+	return m.connect(nil)
+}
+
+// connect dials the server if the manager isn't connected, or connects s with
+// the connection of the manager.
+func (m *Manager) connect(s *Socket) error {
+	m.connMu.Lock()
+	defer m.connMu.Unlock()
+
+	if m.conn != nil && m.conn.alive() {
+		if s != nil {
+			m.conn.connectNamespace(s.namespace, s.getAuth())
+		}
+
+		return nil
+	}
+
+	return m.dial()
+}
+
+// dial dials the server and connects the active sockets. The caller must hold
+// connMu.
+func (m *Manager) dial() error {
+	if _, ok := m.handlers.Get(rootNamespace); !ok {
+		m.createNamespace(rootNamespace)
+	}
+
+	dialer := engineio.Dialer{
+		Transports: m.transports,
+		Version:    m.opts.getEIO(),
+		Logger:     m.logger,
+	}
+
+	enginioCon, err := dialer.Dial(m.url, m.opts.getHeader())
+	if err != nil {
+		return err
+	}
+
+	conn := newConn(enginioCon, m.handlers, m.parser, metrics.Nop{}, m.tracer, m.logger)
+
+	if err := conn.connectClient(m.activeSockets()); err != nil {
+		_ = conn.Close()
+		if root, ok := m.handlers.Get(rootNamespace); ok && root.onError != nil {
+			root.onError(nil, err)
+		}
+
+		return err
+	}
+
+	m.conn = conn
+
+	go m.clientError(conn)
+	go m.clientWrite(conn)
+	go m.clientRead(conn)
+
+	return nil
+}
+
+// activeSockets gives the auth of the namespaces of the sockets to connect.
+func (m *Manager) activeSockets() map[string]interface{} {
+	m.socketsMu.Lock()
+	defer m.socketsMu.Unlock()
+
+	ret := make(map[string]interface{})
+	for namespace, s := range m.sockets {
+		if s.isActive() {
+			ret[namespace] = s.getAuth()
+		}
+	}
+
+	return ret
+}
+
+// Close closes the connection of the manager, without reconnecting.
+func (m *Manager) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.closed)
+	})
+
+	conn := m.getConn()
+	if conn == nil {
+		return nil
+	}
+
+	return conn.Close()
+}
+
+func (m *Manager) getConn() *conn {
+	m.connMu.RLock()
+	defer m.connMu.RUnlock()
+
+	return m.conn
+}
+
+// lost reconnects once conn is lost, if the reconnection is enabled and the
+// manager isn't closed.
+func (m *Manager) lost(conn *conn) {
+	if m.reconnection == nil {
+		return
+	}
+
+	select {
+	case <-m.closed:
+		return
+	default:
+	}
+
+	go m.reconnect(conn)
+}
+
+// reconnect dials the server until it's connected again after lost, unless
+// the manager is connected meanwhile, like by Connect.
+func (m *Manager) reconnect(lost *conn) {
+	attempts := m.reconnection.getAttempts()
+
+	for attempt := 1; attempts == 0 || attempt <= attempts; attempt++ {
+		select {
+		case <-time.After(m.reconnection.backoff(attempt)):
+		case <-m.closed:
+			return
+		}
+
+		m.dispatchReconnection(lost, reconnectAttemptEvent, reflect.ValueOf(attempt))
+
+		m.connMu.Lock()
+		if m.conn != nil && m.conn != lost && m.conn.alive() {
+			m.connMu.Unlock()
+			return
+		}
+		err := m.dial()
+		m.connMu.Unlock()
+
+		if err != nil {
+			m.logger.Info("reconnect", "attempt", attempt, logger.Err(err))
+			continue
+		}
+
+		select {
+		case <-m.closed:
+			_ = m.getConn().Close()
+			return
+		default:
+		}
+
+		m.dispatchReconnection(m.getConn(), reconnectEvent, reflect.ValueOf(attempt))
+		return
+	}
+
+	m.dispatchReconnection(lost, reconnectFailedEvent)
+}
+
+// dispatchReconnection dispatches the reconnection event to the handlers of
+// the active sockets, with their namespace conns of conn.
+func (m *Manager) dispatchReconnection(conn *conn, event string, args ...reflect.Value) {
+	namespaces := make([]string, 0)
+	for namespace := range m.activeSockets() {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		handler, ok := m.handlers.Get(namespace)
+		if !ok {
+			continue
+		}
+
+		nc, ok := conn.namespaces.Get(namespace)
+		if !ok {
+			if nc, ok = conn.namespaces.Get(rootNamespace); !ok {
+				continue
+			}
+		}
+
+		if _, err := handler.dispatchEvent(nc, event, args...); err != nil {
+			conn.log(namespace).Info("dispatch reconnection event", logger.EventKey, event, logger.Err(err))
+		}
+	}
+}
+
+func (m *Manager) clientError(conn *conn) {
+	defer func() {
+		if err := conn.Close(); err != nil {
+			conn.logger.Error("close connect", logger.Err(err))
+		}
+	}()
+
+	for {
+		select {
+		case <-conn.quitChan:
+			return
+		case err := <-conn.errorChan:
+			conn.log(rootNamespace).Error("client error", logger.Err(err))
+
+			var errMsg *errorMessage
+			if !errors.As(err, &errMsg) {
+				continue
+			}
+
+			if handler := conn.namespace(errMsg.namespace); handler != nil {
+				if handler.onError != nil {
+					nsConn, ok := conn.namespaces.Get(errMsg.namespace)
+					if !ok {
+						continue
+					}
+					handler.onError(nsConn, errMsg.err)
+				}
+			}
+		}
+	}
+}
+
+func (m *Manager) clientWrite(conn *conn) {
+	defer func() {
+		if err := conn.Close(); err != nil {
+			conn.logger.Error("close connect", logger.Err(err))
+		}
+	}()
+
+	for {
+		select {
+		case <-conn.quitChan:
+			conn.log(rootNamespace).Info("client writer loop has stopped")
+			return
+		case pkg := <-conn.writeChan:
+			if err := conn.encode(pkg); err != nil {
+				conn.onError(pkg.Header.Namespace, err)
+			}
+		}
+	}
+}
+
+func (m *Manager) clientRead(conn *conn) {
+	reason := clientDisconnectMsg
+
+	defer func() {
+		if err := conn.closeWith(reason); err != nil {
+			conn.logger.Error("close connect", logger.Err(err))
+		}
+
+		m.lost(conn)
+	}()
+
+	var event string
+
+	for {
+		var header parser.Header
+
+		if err := conn.decoder.DecodeHeader(&header, &event); err != nil {
+			if errors.Is(err, engineio.ErrPingTimeout) {
+				reason = pingTimeoutMsg
+			}
+
+			conn.onError(rootNamespace, err)
+
+			conn.log(rootNamespace).Error("decode header", logger.Err(err))
+
+			return
+		}
+
+		if header.Namespace == aliasRootNamespace {
+			header.Namespace = rootNamespace
+		}
+
+		var err error
+		switch header.Type {
+		case parser.Ack:
+			err = ackPacketHandler(conn, header)
+		case parser.Connect:
+			err = clientConnectPacketHandler(conn, header)
+			if err == nil {
+				m.flushBuffer(conn, header.Namespace)
+			}
+		case parser.Disconnect:
+			err = clientDisconnectPacketHandler(conn, header)
+		case parser.Error:
+			err = clientErrorPacketHandler(conn, header)
+		case parser.Event:
+			err = eventPacketHandler(conn, event, header)
+		default:
+
+		}
+
+		if err != nil {
+			conn.log(header.Namespace).Error("client read", logger.Err(err))
+
+			return
+		}
+	}
+}
+
+// flushBuffer sends the buffered emits of the namespace connected with conn.
+func (m *Manager) flushBuffer(conn *conn, namespace string) {
+	if m.buffer == nil {
+		return
+	}
+
+	if nc, ok := conn.namespaces.Get(namespace); ok {
+		go m.buffer.flush(nc)
+	}
+}
+
+func (m *Manager) createNamespace(ns string) *namespaceHandler {
+	handler := newNamespaceHandler(ns, m.tracer, nil)
+	m.handlers.Set(ns, handler)
+
+	return handler
+}
+
+func (m *Manager) getNamespace(ns string) *namespaceHandler {
+	ret, ok := m.handlers.Get(ns)
+	if !ok {
+		return nil
+	}
+
+	return ret
+}
+
+// connectClient connects the root namespace, and the namespaces with their
+// auth.
+func (c *conn) connectClient(namespaces map[string]interface{}) error {
+	rootHandler, ok := c.handlers.Get(rootNamespace)
+	if !ok {
+		return errUnavailableRootHandler
+	}
+
+	root := newNamespaceConn(c, aliasRootNamespace, rootHandler.broadcast)
+	c.namespaces.Set(rootNamespace, root)
+
+	root.Join(root.Conn.ID())
+
+	if err := c.encode(connectPacket(rootNamespace, namespaces[rootNamespace])); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		if namespace != rootNamespace {
+			names = append(names, namespace)
+		}
+	}
+	sort.Strings(names)
+
+	for _, namespace := range names {
+		if err := c.encode(connectPacket(namespace, namespaces[namespace])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// connectNamespace connects the namespace with auth once the connection is
+// served.
+func (c *conn) connectNamespace(namespace string, auth interface{}) {
+	select {
+	case c.writeChan <- connectPacket(namespace, auth):
+	case <-c.quitChan:
+	}
+}
+
+// connectPacket gives the connect packet of namespace, whose data is auth if
+// it isn't nil.
+func connectPacket(namespace string, auth interface{}) parser.Payload {
+	pkg := parser.Payload{
+		Header: parser.Header{
+			Type:      parser.Connect,
+			Namespace: namespace,
+		},
+	}
+
+	if namespace == rootNamespace {
+		pkg.Header.Namespace = ""
+	}
+
+	if auth != nil {
+		pkg.Data = []interface{}{auth}
+	}
+
+	return pkg
+}
+
+// alive tells if the connection isn't closed.
+func (c *conn) alive() bool {
+	select {
+	case <-c.quitChan:
+		return false
+	default:
+		return true
+	}
+}
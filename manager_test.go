@@ -0,0 +1,130 @@
+package socketio
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/metrics"
+	"github.com/googollee/go-socket.io/parser"
+)
+
+func TestManagerSockets(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	srv := NewServer(nil)
+
+	conns := make(chan Conn, 2)
+	disconnected := make(chan string, 1)
+	received := make(chan string, 1)
+	srv.OnConnect("/", func(Conn) error { return nil })
+	for _, namespace := range []string{"/chat", "/admin"} {
+		srv.OnConnect(namespace, func(c Conn) error {
+			conns <- c
+			return nil
+		})
+	}
+	srv.OnDisconnect("/admin", func(c Conn, _ string) {
+		disconnected <- c.Namespace()
+	})
+	srv.OnEvent("/chat", "msg", func(_ Conn, msg string) {
+		received <- msg
+	})
+
+	go func() {
+		_ = srv.Serve()
+	}()
+
+	hs := httptest.NewServer(srv)
+	defer hs.Close()
+	defer srv.Close()
+
+	m, err := NewManager(hs.URL, &ClientOptions{EIO: 3})
+	must.NoError(err)
+	defer m.Close()
+
+	should.Same(m.Socket("/chat"), m.Socket("/chat"))
+
+	connected := make(chan string, 2)
+	chat := m.Socket("/chat")
+	chat.OnConnect(func(c Conn) error {
+		connected <- c.Namespace()
+		return nil
+	})
+
+	admin := m.Socket("/admin")
+	admin.SetAuth(map[string]interface{}{"token": "secret"})
+	admin.OnConnect(func(c Conn) error {
+		connected <- c.Namespace()
+		return nil
+	})
+
+	next := func(ch chan string) string {
+		select {
+		case s := <-ch:
+			return s
+		case <-time.After(5 * time.Second):
+			must.FailNow("timeout")
+		}
+		return ""
+	}
+
+	must.NoError(chat.Connect())
+	should.Equal("/chat", next(connected))
+
+	must.NoError(admin.Connect())
+	should.Equal("/admin", next(connected))
+
+	chatConn, adminConn := <-conns, <-conns
+	if chatConn.Namespace() != "/chat" {
+		chatConn, adminConn = adminConn, chatConn
+	}
+	defer chatConn.Close()
+
+	should.Equal(chatConn.ID(), adminConn.ID())
+	should.Nil(chatConn.Handshake().Auth)
+	should.Equal(map[string]interface{}{"token": "secret"}, adminConn.Handshake().Auth)
+	should.True(admin.Connected())
+
+	must.NoError(admin.Disconnect())
+	should.Equal("/admin", next(disconnected))
+	should.False(admin.Connected())
+
+	chat.Emit("msg", "hello")
+	should.Equal("hello", next(received))
+}
+
+func TestManagerReconnectConnected(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	var dials int32
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&dials, 1)
+		http.NotFound(w, nil)
+	}))
+	defer hs.Close()
+
+	m, err := NewManager(hs.URL, nil)
+	must.NoError(err)
+	m.Reconnection(&ReconnectionOptions{Delay: time.Millisecond, Attempts: 1})
+
+	lost := newConn(&fakeEngineConn{id: "lost"}, m.handlers, parser.JSON, metrics.Nop{}, newTracer(), logger.Log)
+	close(lost.quitChan)
+
+	// the manager is connected again, like by Connect, before the
+	// reconnection dials.
+	m.conn = newConn(&fakeEngineConn{id: "sid"}, m.handlers, parser.JSON, metrics.Nop{}, newTracer(), logger.Log)
+
+	m.reconnect(lost)
+
+	should.Equal(int32(0), atomic.LoadInt32(&dials))
+	should.Equal("sid", m.getConn().ID())
+}
@@ -0,0 +1,63 @@
+// Package metrics defines the hooks which engine.io and socket.io servers use
+// to report what happens on them.
+package metrics
+
+import "time"
+
+// OtherEvent is the event of the events received without handler, whose names
+// are chosen by the clients.
+const OtherEvent = "other"
+
+// Metrics receives measurements from servers. Implementations must be safe for
+// concurrent use, since hooks are called from the goroutines of all
+// connections.
+type Metrics interface {
+	// SessionOpened is called when an engine.io session is opened with transport.
+	SessionOpened(transport string)
+	// SessionClosed is called when an engine.io session opened with transport is
+	// closed, even if it was upgraded to another transport.
+	SessionClosed(transport string)
+	// BytesReceived is called with the size of each frame read from transport.
+	BytesReceived(transport string, n int)
+	// BytesSent is called with the size of each frame written to transport.
+	BytesSent(transport string, n int)
+	// UpgradeSucceeded is called when a session upgrades from one transport to another.
+	UpgradeSucceeded(from, to string)
+	// UpgradeFailed is called when a session fails to upgrade from one transport to another.
+	UpgradeFailed(from, to string)
+
+	// SocketConnected is called when a socket connects to namespace.
+	SocketConnected(namespace string)
+	// SocketDisconnected is called when a socket disconnects from namespace.
+	SocketDisconnected(namespace string)
+	// EventReceived is called when a socket in namespace receives event,
+	// which is OtherEvent if the namespace has no handler of the event.
+	EventReceived(namespace, event string)
+	// EventSent is called when event is sent to a socket in namespace.
+	EventSent(namespace, event string)
+	// AckLatency is called with the time between emitting an event and receiving its ack.
+	AckLatency(namespace string, latency time.Duration)
+
+	// AdapterPublished is called with the time taken to publish a broadcast to the adapter.
+	AdapterPublished(namespace string, d time.Duration)
+	// AdapterReceived is called with the time between publishing a broadcast on
+	// another node and receiving it on this node.
+	AdapterReceived(namespace string, lag time.Duration)
+}
+
+// Nop is a Metrics which discards all measurements. It is the default of servers.
+type Nop struct{}
+
+func (Nop) SessionOpened(string)                   {}
+func (Nop) SessionClosed(string)                   {}
+func (Nop) BytesReceived(string, int)              {}
+func (Nop) BytesSent(string, int)                  {}
+func (Nop) UpgradeSucceeded(string, string)        {}
+func (Nop) UpgradeFailed(string, string)           {}
+func (Nop) SocketConnected(string)                 {}
+func (Nop) SocketDisconnected(string)              {}
+func (Nop) EventReceived(string, string)           {}
+func (Nop) EventSent(string, string)               {}
+func (Nop) AckLatency(string, time.Duration)       {}
+func (Nop) AdapterPublished(string, time.Duration) {}
+func (Nop) AdapterReceived(string, time.Duration)  {}
@@ -0,0 +1,160 @@
+// Package prometheus exports the measurements of servers as prometheus
+// collectors.
+//
+//	m := prometheus.New("socketio")
+//	promclient.MustRegister(m)
+//
+//	server := socketio.NewServer(&engineio.Options{
+//		Metrics: m,
+//	})
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics is a metrics.Metrics which is also a prometheus.Collector.
+type Metrics struct {
+	sessions *prometheus.GaugeVec
+	bytes    *prometheus.CounterVec
+	upgrades *prometheus.CounterVec
+
+	sockets    *prometheus.GaugeVec
+	events     *prometheus.CounterVec
+	ackLatency *prometheus.HistogramVec
+
+	adapterPublish *prometheus.HistogramVec
+	adapterLag     *prometheus.HistogramVec
+}
+
+// New returns a Metrics which names all collectors with the prefix namespace.
+func New(namespace string) *Metrics {
+	return &Metrics{
+		sessions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "engineio_sessions",
+			Help:      "Number of open engine.io sessions.",
+		}, []string{"transport"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "engineio_transport_bytes_total",
+			Help:      "Bytes read from and written to transports.",
+		}, []string{"transport", "direction"}),
+		upgrades: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "engineio_upgrades_total",
+			Help:      "Transport upgrades of engine.io sessions.",
+		}, []string{"from", "to", "result"}),
+
+		sockets: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "socketio_sockets",
+			Help:      "Number of connected sockets.",
+		}, []string{"namespace"}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "socketio_events_total",
+			Help:      "Events received and sent.",
+		}, []string{"namespace", "event", "direction"}),
+		ackLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "socketio_ack_latency_seconds",
+			Help:      "Time between emitting an event and receiving its ack.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"namespace"}),
+
+		adapterPublish: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "socketio_adapter_publish_seconds",
+			Help:      "Time taken to publish a broadcast to the adapter.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"namespace"}),
+		adapterLag: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "socketio_adapter_lag_seconds",
+			Help:      "Time between publishing a broadcast on a node and receiving it on another.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"namespace"}),
+	}
+}
+
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.sessions,
+		m.bytes,
+		m.upgrades,
+		m.sockets,
+		m.events,
+		m.ackLatency,
+		m.adapterPublish,
+		m.adapterLag,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (m *Metrics) SessionOpened(transport string) {
+	m.sessions.WithLabelValues(transport).Inc()
+}
+
+func (m *Metrics) SessionClosed(transport string) {
+	m.sessions.WithLabelValues(transport).Dec()
+}
+
+func (m *Metrics) BytesReceived(transport string, n int) {
+	m.bytes.WithLabelValues(transport, "in").Add(float64(n))
+}
+
+func (m *Metrics) BytesSent(transport string, n int) {
+	m.bytes.WithLabelValues(transport, "out").Add(float64(n))
+}
+
+func (m *Metrics) UpgradeSucceeded(from, to string) {
+	m.upgrades.WithLabelValues(from, to, "success").Inc()
+}
+
+func (m *Metrics) UpgradeFailed(from, to string) {
+	m.upgrades.WithLabelValues(from, to, "failure").Inc()
+}
+
+func (m *Metrics) SocketConnected(namespace string) {
+	m.sockets.WithLabelValues(namespace).Inc()
+}
+
+func (m *Metrics) SocketDisconnected(namespace string) {
+	m.sockets.WithLabelValues(namespace).Dec()
+}
+
+func (m *Metrics) EventReceived(namespace, event string) {
+	m.events.WithLabelValues(namespace, event, "in").Inc()
+}
+
+func (m *Metrics) EventSent(namespace, event string) {
+	m.events.WithLabelValues(namespace, event, "out").Inc()
+}
+
+func (m *Metrics) AckLatency(namespace string, latency time.Duration) {
+	m.ackLatency.WithLabelValues(namespace).Observe(latency.Seconds())
+}
+
+func (m *Metrics) AdapterPublished(namespace string, d time.Duration) {
+	m.adapterPublish.WithLabelValues(namespace).Observe(d.Seconds())
+}
+
+func (m *Metrics) AdapterReceived(namespace string, lag time.Duration) {
+	m.adapterLag.WithLabelValues(namespace).Observe(lag.Seconds())
+}
@@ -0,0 +1,57 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/googollee/go-socket.io/metrics"
+)
+
+var _ metrics.Metrics = &Metrics{}
+
+func TestMetrics(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	m := New("test")
+
+	registry := prometheus.NewRegistry()
+	must.NoError(registry.Register(m))
+
+	m.SessionOpened("polling")
+	m.SessionOpened("polling")
+	m.SessionClosed("polling")
+	m.BytesReceived("polling", 10)
+	m.BytesSent("polling", 20)
+	m.UpgradeSucceeded("polling", "websocket")
+	m.UpgradeFailed("polling", "websocket")
+	m.SocketConnected("/chat")
+	m.EventReceived("/chat", "msg")
+	m.EventSent("/chat", "msg")
+	m.EventSent("/chat", "msg")
+	m.AckLatency("/chat", time.Millisecond)
+	m.AdapterPublished("/chat", time.Millisecond)
+	m.AdapterReceived("/chat", time.Millisecond)
+
+	should.Equal(float64(1), testutil.ToFloat64(m.sessions.WithLabelValues("polling")))
+	should.Equal(float64(10), testutil.ToFloat64(m.bytes.WithLabelValues("polling", "in")))
+	should.Equal(float64(20), testutil.ToFloat64(m.bytes.WithLabelValues("polling", "out")))
+	should.Equal(float64(1), testutil.ToFloat64(m.upgrades.WithLabelValues("polling", "websocket", "success")))
+	should.Equal(float64(1), testutil.ToFloat64(m.upgrades.WithLabelValues("polling", "websocket", "failure")))
+	should.Equal(float64(1), testutil.ToFloat64(m.sockets.WithLabelValues("/chat")))
+	should.Equal(float64(1), testutil.ToFloat64(m.events.WithLabelValues("/chat", "msg", "in")))
+	should.Equal(float64(2), testutil.ToFloat64(m.events.WithLabelValues("/chat", "msg", "out")))
+
+	count, err := testutil.GatherAndCount(registry,
+		"test_socketio_ack_latency_seconds",
+		"test_socketio_adapter_publish_seconds",
+		"test_socketio_adapter_lag_seconds",
+	)
+	must.NoError(err)
+	should.Equal(3, count)
+}
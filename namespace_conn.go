@@ -1,8 +1,13 @@
 package socketio
 
 import (
+	"context"
 	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/googollee/go-socket.io/parser"
 )
@@ -13,14 +18,29 @@ type Namespace interface {
 	// Context of this connection. You can save one context for one
 	// connection, and share it between all handlers. The handlers
 	// are called in one goroutine, so no need to lock context if it
-	// only accessed in one connection.
+	// only accessed in one connection, except the handlers with an
+	// io.Reader param, see Stream. Use Data for values which are
+	// accessed outside of the handlers.
 	Context() interface{}
 	SetContext(ctx interface{})
 
+	// Data returns the store of this connection in the namespace. It is
+	// safe for concurrent use and is visible in FetchSockets results.
+	Data() *Store
+
 	Namespace() string
+	// Emit emits the event with the args v, and the ack func as the last
+	// arg if any. v isn't modified. A reader arg marked by Stream is streamed
+	// in chunks to the handler param of type io.Reader.
 	Emit(eventName string, v ...interface{})
+	// Compress returns the connection which emits with the compression of
+	// the transport turned on or off, e.g. conn.Compress(false).Emit(...).
+	Compress(compress bool) Conn
 
 	Join(room string)
+	// JoinPresence joins the room and tracks the presence of this
+	// connection with meta. Calling it again updates the metadata.
+	JoinPresence(room string, meta map[string]interface{})
 	Leave(room string)
 	LeaveAll()
 	Rooms() []string
@@ -32,8 +52,13 @@ type namespaceConn struct {
 
 	namespace string
 	context   interface{}
+	data      *Store
+	auth      atomic.Value
+	sid       atomic.Value
+	traceCtx  atomic.Value
 
-	ack sync.Map
+	ack     sync.Map
+	ackTime sync.Map
 }
 
 func newNamespaceConn(conn *conn, namespace string, broadcast Broadcast) *namespaceConn {
@@ -41,6 +66,7 @@ func newNamespaceConn(conn *conn, namespace string, broadcast Broadcast) *namesp
 		conn:      conn,
 		namespace: namespace,
 		broadcast: broadcast,
+		data:      newStore(),
 	}
 }
 
@@ -52,11 +78,76 @@ func (nc *namespaceConn) Context() interface{} {
 	return nc.context
 }
 
+func (nc *namespaceConn) Handshake() Handshake {
+	auth, _ := nc.auth.Load().(map[string]interface{})
+
+	return newHandshake(nc.conn.Conn.Handshake(), auth)
+}
+
+// TraceContext returns the trace context of the event which conn is handling,
+// to start spans in event handlers.
+func TraceContext(conn Conn) context.Context {
+	if nc, ok := conn.(*namespaceConn); ok {
+		return nc.traceContext()
+	}
+
+	return context.Background()
+}
+
+// traceHolder keeps the trace context in an atomic.Value with a consistent type.
+type traceHolder struct {
+	ctx context.Context
+}
+
+func (nc *namespaceConn) traceContext() context.Context {
+	if holder, ok := nc.traceCtx.Load().(traceHolder); ok && holder.ctx != nil {
+		return holder.ctx
+	}
+
+	return context.Background()
+}
+
+func (nc *namespaceConn) setTraceContext(ctx context.Context) {
+	nc.traceCtx.Store(traceHolder{ctx: ctx})
+}
+
+func (nc *namespaceConn) setAuth(auth map[string]interface{}) {
+	if auth != nil {
+		nc.auth.Store(auth)
+	}
+}
+
+// ID returns the sid of the socket given by servers of socket.io v5 when the
+// namespace is connected, or the id of the connection.
+func (nc *namespaceConn) ID() string {
+	if sid, ok := nc.sid.Load().(string); ok {
+		return sid
+	}
+
+	return nc.conn.ID()
+}
+
+func (nc *namespaceConn) setSID(sid string) {
+	nc.sid.Store(sid)
+}
+
+func (nc *namespaceConn) Data() *Store {
+	return nc.data
+}
+
 func (nc *namespaceConn) Namespace() string {
 	return nc.namespace
 }
 
 func (nc *namespaceConn) Emit(eventName string, v ...interface{}) {
+	nc.emit(true, eventName, v...)
+}
+
+func (nc *namespaceConn) Compress(compress bool) Conn {
+	return &compressConn{namespaceConn: nc, compress: compress}
+}
+
+func (nc *namespaceConn) emit(compress bool, eventName string, v ...interface{}) {
 	header := parser.Header{
 		Type: parser.Event,
 	}
@@ -65,18 +156,59 @@ func (nc *namespaceConn) Emit(eventName string, v ...interface{}) {
 		header.Namespace = nc.namespace
 	}
 
-	if l := len(v); l > 0 {
-		last := v[l-1]
-		lastV := reflect.TypeOf(last)
-
-		if lastV.Kind() == reflect.Func {
-			f := newAckFunc(last)
+	// the args are replaced and appended below, which the caller doesn't see.
+	v = append([]interface{}(nil), v...)
 
+	if l := len(v); l > 0 {
+		switch last := v[l-1].(type) {
+		case *ackWaiter:
 			header.ID = nc.conn.nextID()
 			header.NeedAck = true
 
-			nc.ack.Store(header.ID, f)
+			id := header.ID
+			nc.ack.Store(id, last.handler())
+			nc.ackTime.Store(id, time.Now())
+			last.setCancel(func() {
+				nc.ack.Delete(id)
+				nc.ackTime.Delete(id)
+			})
 			v = v[:l-1]
+
+		default:
+			if reflect.TypeOf(last).Kind() == reflect.Func {
+				f := newAckFunc(last)
+
+				header.ID = nc.conn.nextID()
+				header.NeedAck = true
+
+				nc.ack.Store(header.ID, f)
+				nc.ackTime.Store(header.ID, time.Now())
+				v = v[:l-1]
+			}
+		}
+	}
+
+	nc.conn.admin.eventSent(nc, eventName, v)
+
+	if handler := nc.conn.namespace(fmtNS(nc.namespace)); handler != nil {
+		handler.dispatchAnyOutgoing(nc, eventName, v)
+	}
+
+	if nc.conn.tracer.payload {
+		ctx, span := nc.conn.tracer.start(nc.traceContext(), emitSpanName, trace.SpanKindProducer,
+			namespaceAttr.String(namespaceName(header.Namespace)), eventAttr.String(eventName), sidAttr.String(nc.ID()))
+		if carrier := nc.conn.tracer.inject(ctx); len(carrier) > 0 {
+			v = append(v, carrier)
+		}
+		span.End()
+	}
+
+	var refs []streamRef
+	for i := range v {
+		if r, ok := v[i].(streamArg); ok {
+			ref := nc.conn.streams.open(r.Reader)
+			refs = append(refs, ref)
+			v[i] = ref
 		}
 	}
 
@@ -87,19 +219,81 @@ func (nc *namespaceConn) Emit(eventName string, v ...interface{}) {
 		args[i] = reflect.ValueOf(v[i-1])
 	}
 
+	nc.conn.writeWith(compress, header, args...)
+
+	for _, ref := range refs {
+		go nc.conn.streams.send(nc, ref)
+	}
+}
+
+// failAcks fails the pending acks of EmitWithAck with err.
+func (nc *namespaceConn) failAcks(err error) {
+	nc.ack.Range(func(_, f interface{}) bool {
+		if h, ok := f.(*funcHandler); ok && h.fail != nil {
+			h.fail(err)
+		}
+		return true
+	})
+}
+
+// emitStream emits the stream event with v.
+func (nc *namespaceConn) emitStream(event string, v ...interface{}) {
+	header := parser.Header{
+		Type: parser.Event,
+	}
+
+	if nc.namespace != aliasRootNamespace {
+		header.Namespace = nc.namespace
+	}
+
+	args := make([]reflect.Value, len(v)+1)
+	args[0] = reflect.ValueOf(event)
+
+	for i := 1; i < len(args); i++ {
+		args[i] = reflect.ValueOf(v[i-1])
+	}
+
 	nc.conn.write(header, args...)
 }
 
+// compressConn is a namespaceConn emitting with the given compression.
+type compressConn struct {
+	*namespaceConn
+
+	compress bool
+}
+
+func (c *compressConn) Emit(eventName string, v ...interface{}) {
+	c.emit(c.compress, eventName, v...)
+}
+
+func (c *compressConn) Compress(compress bool) Conn {
+	return c.namespaceConn.Compress(compress)
+}
+
 func (nc *namespaceConn) Join(room string) {
 	nc.broadcast.Join(room, nc)
+	nc.conn.admin.roomJoined(nc, room)
+}
+
+func (nc *namespaceConn) JoinPresence(room string, meta map[string]interface{}) {
+	nc.broadcast.JoinPresence(room, nc, meta)
+	nc.conn.admin.roomJoined(nc, room)
 }
 
 func (nc *namespaceConn) Leave(room string) {
 	nc.broadcast.Leave(room, nc)
+	nc.conn.admin.roomLeft(nc, room)
 }
 
 func (nc *namespaceConn) LeaveAll() {
+	rooms := nc.conn.admin.rooms(nc)
+
 	nc.broadcast.LeaveAll(nc)
+
+	for _, room := range rooms {
+		nc.conn.admin.roomLeft(nc, room)
+	}
 }
 
 func (nc *namespaceConn) Rooms() []string {
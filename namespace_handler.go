@@ -1,6 +1,7 @@
 package socketio
 
 import (
+	"encoding/json"
 	"errors"
 	"reflect"
 	"sync"
@@ -12,24 +13,34 @@ type namespaceHandler struct {
 	broadcast Broadcast
 
 	events     map[string]*funcHandler
+	validators map[string]Validator
 	eventsLock sync.RWMutex
 
 	onConnect    func(conn Conn) error
 	onDisconnect func(conn Conn, msg string)
 	onError      func(conn Conn, err error)
+
+	// onAny and onAnyOutgoing are called with the events received and
+	// emitted, guarded by eventsLock.
+	onAny         func(conn Conn, event string, args []interface{})
+	onAnyOutgoing func(conn Conn, event string, args []interface{})
+
+	// afterConnect is called once the connect packet is answered.
+	afterConnect func(conn Conn)
 }
 
-func newNamespaceHandler(nsp string, adapterOpts *RedisAdapterOptions) *namespaceHandler {
+func newNamespaceHandler(nsp string, t *tracer, adapterOpts *RedisAdapterOptions) *namespaceHandler {
 	var broadcast Broadcast
 	if adapterOpts == nil {
-		broadcast = newBroadcast()
+		broadcast = newBroadcast(nsp, t)
 	} else {
 		broadcast, _ = newRedisBroadcast(nsp, adapterOpts)
 	}
 
 	return &namespaceHandler{
-		broadcast: broadcast,
-		events:    make(map[string]*funcHandler),
+		broadcast:  broadcast,
+		events:     make(map[string]*funcHandler),
+		validators: make(map[string]Validator),
 	}
 }
 
@@ -52,6 +63,75 @@ func (nh *namespaceHandler) OnEvent(event string, f interface{}) {
 	nh.events[event] = newEventFunc(f)
 }
 
+// OnceEvent sets the handler f of event, which is removed once called.
+func (nh *namespaceHandler) OnceEvent(event string, f interface{}) {
+	h := newEventFunc(f)
+	h.once = true
+
+	nh.eventsLock.Lock()
+	defer nh.eventsLock.Unlock()
+
+	nh.events[event] = h
+}
+
+// OffEvent removes the handler of event.
+func (nh *namespaceHandler) OffEvent(event string) {
+	nh.eventsLock.Lock()
+	defer nh.eventsLock.Unlock()
+
+	delete(nh.events, event)
+}
+
+// OnAny sets f to be called with each event received, before its handler.
+func (nh *namespaceHandler) OnAny(f func(conn Conn, event string, args []interface{})) {
+	nh.eventsLock.Lock()
+	defer nh.eventsLock.Unlock()
+
+	nh.onAny = f
+}
+
+// OnAnyOutgoing sets f to be called with each event emitted.
+func (nh *namespaceHandler) OnAnyOutgoing(f func(conn Conn, event string, args []interface{})) {
+	nh.eventsLock.Lock()
+	defer nh.eventsLock.Unlock()
+
+	nh.onAnyOutgoing = f
+}
+
+func (nh *namespaceHandler) OnEventValidator(event string, v Validator) {
+	nh.eventsLock.Lock()
+	defer nh.eventsLock.Unlock()
+
+	nh.validators[event] = v
+}
+
+// getValidate gives the validation of the raw args of event, which reports a
+// *ValidationError, or nil if event has no validator.
+func (nh *namespaceHandler) getValidate(event string) func(args []json.RawMessage) error {
+	nh.eventsLock.RLock()
+	v := nh.validators[event]
+	nh.eventsLock.RUnlock()
+
+	if v == nil {
+		return nil
+	}
+
+	return func(args []json.RawMessage) error {
+		if err := v.Validate(args); err != nil {
+			return &ValidationError{Event: event, Message: err.Error()}
+		}
+		return nil
+	}
+}
+
+// hasEvent tells if event has a handler.
+func (nh *namespaceHandler) hasEvent(event string) bool {
+	nh.eventsLock.RLock()
+	defer nh.eventsLock.RUnlock()
+
+	return nh.events[event] != nil
+}
+
 func (nh *namespaceHandler) getEventTypes(event string) []reflect.Type {
 	nh.eventsLock.RLock()
 	namespaceHandler := nh.events[event]
@@ -100,9 +180,58 @@ func (nh *namespaceHandler) dispatchEvent(conn Conn, event string, args ...refle
 		return nil, nil
 	}
 
+	// the once handler is called by the first dispatch removing it.
+	if namespaceHandler.once && !nh.remove(event, namespaceHandler) {
+		return nil, nil
+	}
+
 	return namespaceHandler.Call(append([]reflect.Value{reflect.ValueOf(conn)}, args...))
 }
 
+// remove removes the handler h of event, and tells if it was still set.
+func (nh *namespaceHandler) remove(event string, h *funcHandler) bool {
+	nh.eventsLock.Lock()
+	defer nh.eventsLock.Unlock()
+
+	if nh.events[event] != h {
+		return false
+	}
+
+	delete(nh.events, event)
+	return true
+}
+
+// dispatchAny calls the handler of OnAny with the event received, whose args
+// are decoded for its handler, and rest beyond them.
+func (nh *namespaceHandler) dispatchAny(conn Conn, event string, args []reflect.Value, rest []interface{}) {
+	nh.eventsLock.RLock()
+	f := nh.onAny
+	nh.eventsLock.RUnlock()
+
+	if f == nil {
+		return
+	}
+
+	all := make([]interface{}, 0, len(args)+len(rest))
+	for _, arg := range args {
+		all = append(all, arg.Interface())
+	}
+
+	f(conn, event, append(all, rest...))
+}
+
+// dispatchAnyOutgoing calls the handler of OnAnyOutgoing with the event
+// emitted.
+func (nh *namespaceHandler) dispatchAnyOutgoing(conn Conn, event string, args []interface{}) {
+	nh.eventsLock.RLock()
+	f := nh.onAnyOutgoing
+	nh.eventsLock.RUnlock()
+
+	if f != nil {
+		f(conn, event, args)
+	}
+}
+
 func getDispatchMessage(args ...reflect.Value) string {
 	var msg string
 	if len(args) > 0 {
@@ -14,7 +14,7 @@ func TestNamespaceHandler(t *testing.T) {
 	should := assert.New(t)
 	must := require.New(t)
 
-	h := newNamespaceHandler(t.Name(), nil)
+	h := newNamespaceHandler(t.Name(), newTracer(), nil)
 
 	onConnectCalled := false
 	h.OnConnect(func(c Conn) error {
@@ -103,7 +103,7 @@ func TestNamespaceHandlerEvent(t *testing.T) {
 			should := assert.New(t)
 			must := require.New(t)
 
-			h := newNamespaceHandler(test.name, nil)
+			h := newNamespaceHandler(test.name, newTracer(), nil)
 			for i, e := range test.events {
 				h.OnEvent(e, test.handlers[i])
 			}
@@ -131,3 +131,30 @@ func TestNamespaceHandlerEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestNamespaceHandlerOnce(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	h := newNamespaceHandler("/", newTracer(), nil)
+
+	calls := 0
+	h.OnceEvent("ready", func(Conn) {
+		calls++
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := h.dispatchEvent(&namespaceConn{}, "ready")
+		must.NoError(err)
+	}
+	should.Equal(1, calls)
+
+	h.OnEvent("msg", func(Conn) {
+		calls++
+	})
+	h.OffEvent("msg")
+
+	_, err := h.dispatchEvent(&namespaceConn{}, "msg")
+	must.NoError(err)
+	should.Equal(1, calls)
+}
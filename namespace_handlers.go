@@ -20,6 +20,16 @@ func (h *namespaceHandlers) Set(namespace string, handler *namespaceHandler) {
 	h.handlers[namespace] = handler
 }
 
+// Range calls f for each namespace and its handler.
+func (h *namespaceHandlers) Range(f func(nsp string, handler *namespaceHandler)) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for nsp, handler := range h.handlers {
+		f(nsp, handler)
+	}
+}
+
 func (h *namespaceHandlers) Get(nsp string) (*namespaceHandler, bool) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -61,6 +61,19 @@ func (a *Buffer) encodeBinary(buf *bytes.Buffer) error {
 	return nil
 }
 
+// MarshalBinary gives the data of the buffer, for the encodings with native
+// binary values.
+func (a Buffer) MarshalBinary() ([]byte, error) {
+	return a.Data, nil
+}
+
+// UnmarshalBinary sets the data of the buffer.
+func (a *Buffer) UnmarshalBinary(data []byte) error {
+	a.Data = append([]byte(nil), data...)
+
+	return nil
+}
+
 // UnmarshalJSON unmarshal data from JSON.
 func (a *Buffer) UnmarshalJSON(b []byte) error {
 	var data BufferData
@@ -10,7 +10,6 @@ import (
 	"strings"
 
 	"github.com/googollee/go-socket.io/engineio/session"
-	"github.com/googollee/go-socket.io/logger"
 )
 
 const (
@@ -102,61 +101,114 @@ func (d *Decoder) DecodeHeader(header *Header, event *string) error {
 }
 
 func (d *Decoder) DecodeArgs(types []reflect.Type) ([]reflect.Value, error) {
+	ret, _, err := d.DecodeArgsWithRest(types)
+
+	return ret, err
+}
+
+// DecodeArgsWithRest decodes args like DecodeArgs, and also returns the args
+// beyond types, which DecodeArgs discards, as generic JSON values.
+func (d *Decoder) DecodeArgsWithRest(types []reflect.Type) ([]reflect.Value, []interface{}, error) {
+	return d.DecodeArgsValidated(types, nil)
+}
+
+// DecodeArgsValidated decodes args like DecodeArgsWithRest, once validate
+// accepts the raw JSON of all the args. Binary args are placeholder objects
+// in the raw JSON. The error of validate is returned as is, after the packet
+// is read entirely.
+func (d *Decoder) DecodeArgsValidated(types []reflect.Type, validate func(args []json.RawMessage) error) ([]reflect.Value, []interface{}, error) {
 	r := d.packetReader.(io.Reader)
 	if d.isEvent {
 		r = io.MultiReader(strings.NewReader("["), r)
 	}
 
-	ret := make([]reflect.Value, len(types))
-	values := make([]interface{}, len(types))
-
-	for i, typ := range types {
-		if typ.Kind() == reflect.Ptr {
-			typ = typ.Elem()
-		}
-		ret[i] = reflect.New(typ)
-		values[i] = ret[i].Interface()
-	}
-
-	if err := json.NewDecoder(r).Decode(&values); err != nil {
-		if err == io.EOF {
-			err = nil
-		}
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil && err != io.EOF {
 		_ = d.DiscardLast()
 
-		return nil, err
+		return nil, nil, err
 	}
 
 	//we can't use defer or call DiscardLast before decoding, because
 	//there are buffered readers involved and if we invoke .Close() json will encounter unexpected EOF.
 	_ = d.DiscardLast()
 
-	for i, typ := range types {
-		if typ.Kind() != reflect.Ptr {
-			ret[i] = ret[i].Elem()
-		}
-	}
-
 	buffers := make([]Buffer, d.bufferCount)
 	for i := range buffers {
 		ft, r, err := d.r.NextReader()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		buffers[i].Data, err = d.readBuffer(ft, r)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+	}
+
+	if validate != nil {
+		if err := validate(raw); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ret := make([]reflect.Value, len(types))
+	for i, typ := range types {
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		ret[i] = reflect.New(typ)
+
+		if i < len(raw) {
+			if err := json.Unmarshal(raw[i], ret[i].Interface()); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	var rest []interface{}
+	for i := len(types); i < len(raw); i++ {
+		var value interface{}
+		if err := json.Unmarshal(raw[i], &value); err != nil {
+			return nil, nil, err
+		}
+		rest = append(rest, value)
+	}
+
+	for i, typ := range types {
+		if typ.Kind() != reflect.Ptr {
+			ret[i] = ret[i].Elem()
 		}
 	}
 
 	for i := range ret {
 		if err := d.detachBuffer(ret[i], buffers); err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+	}
+
+	return ret, rest, nil
+}
+
+// DecodeData decodes the data of a packet without event, like the auth payload
+// of a connect packet, into v. v is untouched if the packet has no data.
+func (d *Decoder) DecodeData(v interface{}) error {
+	if _, err := d.packetReader.ReadByte(); err != nil {
+		_ = d.DiscardLast()
+
+		if err == io.EOF {
+			return nil
 		}
+		return err
 	}
+	_ = d.packetReader.UnreadByte()
 
-	return ret, nil
+	err := json.NewDecoder(d.packetReader).Decode(v)
+
+	//same as DecodeArgs, the last frame can be discarded only after decoding.
+	_ = d.DiscardLast()
+
+	return err
 }
 
 func (d *Decoder) readUint64FromText(r byteReader) (uint64, bool, error) {
@@ -329,17 +381,17 @@ func (d *Decoder) readEvent(event *string) error {
 }
 
 func (d *Decoder) readBuffer(ft session.FrameType, r io.ReadCloser) ([]byte, error) {
-	defer func() {
-		if err := r.Close(); err != nil {
-			logger.Error("close reader:", err)
-		}
-	}()
-
 	if ft != session.BINARY {
+		_ = r.Close()
 		return nil, errInvalidBinaryBufferType
 	}
 
-	return ioutil.ReadAll(r)
+	b, err := ioutil.ReadAll(r)
+	if closeErr := r.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	return b, err
 }
 
 func (d *Decoder) detachBuffer(v reflect.Value, buffers []Buffer) error {
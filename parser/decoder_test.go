@@ -2,6 +2,8 @@ package parser
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"github.com/googollee/go-socket.io/engineio/session"
 	"io"
 	"reflect"
@@ -83,3 +85,82 @@ func TestDecoder(t *testing.T) {
 		})
 	}
 }
+
+func TestDecoderData(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		header Header
+		auth   map[string]interface{}
+	}{
+		{"root without auth", "0", Header{Type: Connect}, nil},
+		{"root with auth", `0{"token":"abc"}`, Header{Type: Connect}, map[string]interface{}{"token": "abc"}},
+		{"namespace without auth", "0/chat,", Header{Type: Connect, Namespace: "/chat"}, nil},
+		{"namespace with auth", `0/chat,{"token":"abc"}`, Header{Type: Connect, Namespace: "/chat"}, map[string]interface{}{"token": "abc"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			should := assert.New(t)
+			must := require.New(t)
+
+			decoder := NewDecoder(&fakeReader{data: [][]byte{[]byte(test.data)}})
+
+			var header Header
+			var event string
+			must.NoError(decoder.DecodeHeader(&header, &event))
+			should.Equal(test.header, header)
+
+			var auth map[string]interface{}
+			must.NoError(decoder.DecodeData(&auth))
+			should.Equal(test.auth, auth)
+		})
+	}
+}
+
+func TestDecoderArgsWithRest(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	decoder := NewDecoder(&fakeReader{data: [][]byte{[]byte(`2["msg","hello",{"traceparent":"tp"}]`)}})
+
+	var header Header
+	var event string
+	must.NoError(decoder.DecodeHeader(&header, &event))
+	should.Equal("msg", event)
+
+	args, rest, err := decoder.DecodeArgsWithRest([]reflect.Type{reflect.TypeOf("")})
+	must.NoError(err)
+	must.Len(args, 1)
+	should.Equal("hello", args[0].Interface())
+	should.Equal([]interface{}{map[string]interface{}{"traceparent": "tp"}}, rest)
+}
+
+func TestDecoderArgsValidated(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	invalid := errors.New("invalid")
+	decoder := NewDecoder(&fakeReader{data: [][]byte{[]byte(`2["msg",{"name":"a"}]`)}})
+
+	var header Header
+	var event string
+	must.NoError(decoder.DecodeHeader(&header, &event))
+
+	var raw []json.RawMessage
+	_, _, err := decoder.DecodeArgsValidated([]reflect.Type{reflect.TypeOf(map[string]string{})}, func(args []json.RawMessage) error {
+		raw = args
+		return invalid
+	})
+	should.Equal(invalid, err)
+	should.Equal([]json.RawMessage{json.RawMessage(`{"name":"a"}`)}, raw)
+
+	decoder = NewDecoder(&fakeReader{data: [][]byte{[]byte(`2["msg",{"name":"b"}]`)}})
+	must.NoError(decoder.DecodeHeader(&header, &event))
+
+	args, _, err := decoder.DecodeArgsValidated([]reflect.Type{reflect.TypeOf(map[string]string{})}, func([]json.RawMessage) error {
+		return nil
+	})
+	must.NoError(err)
+	should.Equal(map[string]string{"name": "b"}, args[0].Interface())
+}
@@ -7,7 +7,7 @@ import (
 	"reflect"
 
 	"github.com/googollee/go-socket.io/engineio/session"
-	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/engineio/transport"
 )
 
 type FrameWriter interface {
@@ -24,35 +24,37 @@ func NewEncoder(w FrameWriter) *Encoder {
 	}
 }
 
-func (e *Encoder) Encode(h Header, args ...interface{}) (err error) {
+func (e *Encoder) Encode(h Header, args ...interface{}) error {
+	return e.encode(h, true, args)
+}
+
+// EncodeUncompressed encodes like Encode, but asks the transport to send the
+// frames of the packet uncompressed.
+func (e *Encoder) EncodeUncompressed(h Header, args ...interface{}) error {
+	return e.encode(h, false, args)
+}
+
+func (e *Encoder) encode(h Header, compress bool, args []interface{}) (err error) {
 	var w io.WriteCloser
-	w, err = e.w.NextWriter(session.TEXT)
+	w, err = e.nextWriter(session.TEXT, compress)
 	if err != nil {
-		logger.Error("next writer session text:", err)
-
 		return
 	}
 
 	var buffers [][]byte
 	buffers, err = e.writePacket(w, h, args)
 	if err != nil {
-		logger.Error("write packet header with args:", err)
-
 		return
 	}
 
 	for _, b := range buffers {
-		w, err = e.w.NextWriter(session.BINARY)
+		w, err = e.nextWriter(session.BINARY, compress)
 		if err != nil {
-			logger.Error("next writer session binary:", err)
-
 			return
 		}
 
 		err = e.writeBuffer(w, b)
 		if err != nil {
-			logger.Error("write packet buffer:", err)
-
 			return
 		}
 	}
@@ -60,6 +62,21 @@ func (e *Encoder) Encode(h Header, args ...interface{}) (err error) {
 	return
 }
 
+func (e *Encoder) nextWriter(ft session.FrameType, compress bool) (io.WriteCloser, error) {
+	w, err := e.w.NextWriter(ft)
+	if err != nil {
+		return nil, err
+	}
+
+	if !compress {
+		if c, ok := w.(transport.Compressor); ok {
+			c.SetCompress(false)
+		}
+	}
+
+	return w, nil
+}
+
 type byteWriter interface {
 	io.Writer
 	WriteByte(byte) error
@@ -69,10 +86,10 @@ type flusher interface {
 	Flush() error
 }
 
-func (e *Encoder) writePacket(w io.WriteCloser, h Header, args []interface{}) ([][]byte, error) {
+func (e *Encoder) writePacket(w io.WriteCloser, h Header, args []interface{}) (buffers [][]byte, err error) {
 	defer func() {
-		if err := w.Close(); err != nil {
-			logger.Error("close writer:", err)
+		if closeErr := w.Close(); closeErr != nil && err == nil {
+			buffers, err = nil, closeErr
 		}
 	}()
 
@@ -82,7 +99,7 @@ func (e *Encoder) writePacket(w io.WriteCloser, h Header, args []interface{}) ([
 	}
 
 	max := uint64(0)
-	buffers, err := e.attachBuffer(reflect.ValueOf(args), &max)
+	buffers, err = e.attachBuffer(reflect.ValueOf(args), &max)
 	if err != nil {
 		return nil, err
 	}
@@ -204,12 +221,10 @@ func (e *Encoder) attachBuffer(v reflect.Value, index *uint64) ([][]byte, error)
 }
 
 func (e *Encoder) writeBuffer(w io.WriteCloser, buffer []byte) error {
-	defer func() {
-		if closeErr := w.Close(); closeErr != nil {
-			logger.Error("close writer:", closeErr)
-		}
-	}()
+	if _, err := w.Write(buffer); err != nil {
+		_ = w.Close()
+		return err
+	}
 
-	_, err := w.Write(buffer)
-	return err
+	return w.Close()
 }
@@ -122,3 +122,68 @@ func TestAttachBuffer(t *testing.T) {
 		})
 	}
 }
+
+type compressWriter struct {
+	fakeWriter
+
+	compress []bool
+}
+
+func (w *compressWriter) NextWriter(ft session.FrameType) (io.WriteCloser, error) {
+	if _, err := w.fakeWriter.NextWriter(ft); err != nil {
+		return nil, err
+	}
+
+	w.compress = append(w.compress, true)
+
+	return w, nil
+}
+
+func (w *compressWriter) SetCompress(compress bool) {
+	w.compress[len(w.compress)-1] = compress
+}
+
+func TestEncoderUncompressed(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	w := compressWriter{}
+	encoder := NewEncoder(&w)
+
+	must.NoError(encoder.Encode(Header{Type: Event}, []interface{}{"msg", "hello"}))
+	must.NoError(encoder.EncodeUncompressed(Header{Type: Event}, []interface{}{"msg", &Buffer{Data: []byte{1}}}))
+
+	should.Equal([]bool{true, false, false}, w.compress)
+	should.Equal(`2["msg","hello"]`+"\n", w.data[0].String())
+	should.Equal(`51-["msg",{"_placeholder":true,"num":0}]`+"\n", w.data[1].String())
+}
+
+type closeErrorWriter struct {
+	fakeWriter
+
+	err error
+}
+
+func (w *closeErrorWriter) NextWriter(ft session.FrameType) (io.WriteCloser, error) {
+	if _, err := w.fakeWriter.NextWriter(ft); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *closeErrorWriter) Close() error {
+	_ = w.fakeWriter.Close()
+
+	return w.err
+}
+
+func TestEncoderCloseError(t *testing.T) {
+	should := assert.New(t)
+
+	w := closeErrorWriter{err: io.ErrClosedPipe}
+	encoder := NewEncoder(&w)
+
+	should.Equal(io.ErrClosedPipe, encoder.Encode(Header{Type: Event}, []interface{}{"msg", "hello"}))
+	should.Len(w.data, 1)
+}
@@ -0,0 +1,272 @@
+// Package msgpack implements a socket.io parser compatible with
+// socket.io-msgpack-parser. Each packet is one binary frame holding a
+// MessagePack map, with binary args kept inline instead of split into
+// attachments.
+package msgpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	mp "github.com/vmihailenco/msgpack/v5"
+
+	"github.com/googollee/go-socket.io/engineio/session"
+	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/parser"
+)
+
+const rootNamespace = "/"
+
+var errInvalidFrameType = errors.New("msgpack packet should be binary frame")
+
+// Parser is the msgpack parser, to use with the socket.io-msgpack-parser of
+// the clients.
+var Parser parser.Parser = msgpackParser{}
+
+type msgpackParser struct{}
+
+func (msgpackParser) NewEncoder(w parser.FrameWriter) parser.PacketEncoder {
+	return &encoder{w: w}
+}
+
+func (msgpackParser) NewDecoder(r parser.FrameReader) parser.PacketDecoder {
+	return &decoder{r: r}
+}
+
+type outPacket struct {
+	Type parser.Type `msgpack:"type"`
+	Data interface{} `msgpack:"data,omitempty"`
+	Nsp  string      `msgpack:"nsp"`
+	ID   *uint64     `msgpack:"id,omitempty"`
+}
+
+type inPacket struct {
+	Type parser.Type   `msgpack:"type"`
+	Data mp.RawMessage `msgpack:"data"`
+	Nsp  string        `msgpack:"nsp"`
+	ID   *uint64       `msgpack:"id"`
+}
+
+type encoder struct {
+	w parser.FrameWriter
+}
+
+func (e *encoder) Encode(h parser.Header, args ...interface{}) error {
+	return e.encode(h, true, args)
+}
+
+func (e *encoder) EncodeUncompressed(h parser.Header, args ...interface{}) error {
+	return e.encode(h, false, args)
+}
+
+func (e *encoder) encode(h parser.Header, compress bool, args []interface{}) error {
+	p := outPacket{
+		Type: h.Type,
+		Nsp:  h.Namespace,
+	}
+	if p.Nsp == "" {
+		p.Nsp = rootNamespace
+	}
+	if h.NeedAck {
+		id := h.ID
+		p.ID = &id
+	}
+	if len(args) > 0 {
+		p.Data = args[0]
+	}
+
+	var buf bytes.Buffer
+	enc := mp.NewEncoder(&buf)
+	enc.UseCompactInts(true)
+	if err := enc.Encode(&p); err != nil {
+		return err
+	}
+
+	w, err := e.w.NextWriter(session.BINARY)
+	if err != nil {
+		return err
+	}
+
+	if c, ok := w.(transport.Compressor); ok && !compress {
+		c.SetCompress(false)
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+type decoder struct {
+	r parser.FrameReader
+
+	data mp.RawMessage
+	args []mp.RawMessage
+}
+
+func (d *decoder) DecodeHeader(header *parser.Header, event *string) error {
+	d.data, d.args = nil, nil
+
+	ft, r, err := d.r.NextReader()
+	if err != nil {
+		return err
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if closeErr := r.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if ft != session.BINARY {
+		return errInvalidFrameType
+	}
+
+	var p inPacket
+	if err := mp.Unmarshal(b, &p); err != nil {
+		return err
+	}
+
+	if p.Type > parser.Error {
+		return parser.ErrInvalidPacketType
+	}
+
+	*header = parser.Header{Type: p.Type}
+	if p.ID != nil {
+		header.ID = *p.ID
+		header.NeedAck = true
+	}
+
+	header.Namespace = p.Nsp
+	if i := strings.IndexByte(header.Namespace, '?'); i > -1 {
+		header.Query = header.Namespace[i+1:]
+		header.Namespace = header.Namespace[:i]
+	}
+	if header.Namespace == rootNamespace {
+		header.Namespace = ""
+	}
+
+	d.data = p.Data
+	if len(p.Data) == 0 {
+		return nil
+	}
+
+	// The data of events and acks is the array of args, the data of other
+	// packets is a single arg.
+	if err := mp.Unmarshal(p.Data, &d.args); err != nil {
+		if p.Type == parser.Event || p.Type == parser.Ack {
+			return err
+		}
+		d.args = []mp.RawMessage{p.Data}
+	}
+
+	if p.Type == parser.Event && len(d.args) > 0 {
+		if err := mp.Unmarshal(d.args[0], event); err != nil {
+			return err
+		}
+		d.args = d.args[1:]
+	}
+
+	return nil
+}
+
+func (d *decoder) DecodeArgs(types []reflect.Type) ([]reflect.Value, error) {
+	ret, _, err := d.DecodeArgsWithRest(types)
+
+	return ret, err
+}
+
+func (d *decoder) DecodeArgsWithRest(types []reflect.Type) ([]reflect.Value, []interface{}, error) {
+	return d.DecodeArgsValidated(types, nil)
+}
+
+// DecodeArgsValidated decodes the args into types once validate accepts them.
+// validate gets the args converted to JSON, where binary values are base64
+// strings.
+func (d *decoder) DecodeArgsValidated(types []reflect.Type, validate func(args []json.RawMessage) error) ([]reflect.Value, []interface{}, error) {
+	args := d.args
+	d.data, d.args = nil, nil
+
+	if validate != nil {
+		raw := make([]json.RawMessage, len(args))
+		for i := range args {
+			var value interface{}
+			if err := mp.Unmarshal(args[i], &value); err != nil {
+				return nil, nil, err
+			}
+
+			b, err := json.Marshal(value)
+			if err != nil {
+				return nil, nil, err
+			}
+			raw[i] = b
+		}
+
+		if err := validate(raw); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ret := make([]reflect.Value, len(types))
+	for i, typ := range types {
+		isPtr := typ.Kind() == reflect.Ptr
+		if isPtr {
+			typ = typ.Elem()
+		}
+		ret[i] = reflect.New(typ)
+
+		if i < len(args) {
+			if err := mp.Unmarshal(args[i], ret[i].Interface()); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if !isPtr {
+			ret[i] = ret[i].Elem()
+		}
+	}
+
+	var rest []interface{}
+	for i := len(types); i < len(args); i++ {
+		var value interface{}
+		if err := mp.Unmarshal(args[i], &value); err != nil {
+			return nil, nil, err
+		}
+		rest = append(rest, value)
+	}
+
+	return ret, rest, nil
+}
+
+// DecodeData decodes the data of a packet without event, like the auth payload
+// of a connect packet, into v. v is untouched if the packet has no data.
+func (d *decoder) DecodeData(v interface{}) error {
+	data := d.data
+	d.data, d.args = nil, nil
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	return mp.Unmarshal(data, v)
+}
+
+// DiscardLast discards the rest of the last packet, which is read entirely by
+// DecodeHeader.
+func (d *decoder) DiscardLast() error {
+	d.data, d.args = nil, nil
+
+	return nil
+}
+
+func (d *decoder) Close() error {
+	return d.DiscardLast()
+}
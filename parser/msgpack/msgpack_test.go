@@ -0,0 +1,144 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	mp "github.com/vmihailenco/msgpack/v5"
+
+	"github.com/googollee/go-socket.io/engineio/session"
+	"github.com/googollee/go-socket.io/parser"
+)
+
+type frame struct {
+	ft       session.FrameType
+	data     []byte
+	compress bool
+}
+
+type fakeFrames struct {
+	frames  []frame
+	current *bytes.Buffer
+}
+
+func (f *fakeFrames) NextWriter(ft session.FrameType) (io.WriteCloser, error) {
+	f.current = bytes.NewBuffer(nil)
+	f.frames = append(f.frames, frame{ft: ft, compress: true})
+
+	return f, nil
+}
+
+func (f *fakeFrames) Write(p []byte) (int, error) {
+	return f.current.Write(p)
+}
+
+func (f *fakeFrames) SetCompress(compress bool) {
+	f.frames[len(f.frames)-1].compress = compress
+}
+
+func (f *fakeFrames) Close() error {
+	f.frames[len(f.frames)-1].data = f.current.Bytes()
+
+	return nil
+}
+
+func (f *fakeFrames) NextReader() (session.FrameType, io.ReadCloser, error) {
+	if len(f.frames) == 0 {
+		return 0, nil, io.EOF
+	}
+
+	fr := f.frames[0]
+	f.frames = f.frames[1:]
+
+	return fr.ft, io.NopCloser(bytes.NewReader(fr.data)), nil
+}
+
+func TestEncode(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	f := &fakeFrames{}
+	encoder := Parser.NewEncoder(f)
+
+	must.NoError(encoder.Encode(parser.Header{Type: parser.Event, ID: 3, NeedAck: true}, []interface{}{"msg", parser.Buffer{Data: []byte{1, 2}}}))
+	must.NoError(encoder.EncodeUncompressed(parser.Header{Type: parser.Connect, Namespace: "/chat"}))
+
+	must.Len(f.frames, 2)
+	should.Equal(session.BINARY, f.frames[0].ft)
+	should.True(f.frames[0].compress)
+	should.False(f.frames[1].compress)
+
+	var event map[string]interface{}
+	must.NoError(mp.Unmarshal(f.frames[0].data, &event))
+	should.Equal(map[string]interface{}{
+		"type": int8(2),
+		"data": []interface{}{"msg", []byte{1, 2}},
+		"nsp":  "/",
+		"id":   int8(3),
+	}, event)
+
+	var connect map[string]interface{}
+	must.NoError(mp.Unmarshal(f.frames[1].data, &connect))
+	should.Equal(map[string]interface{}{"type": int8(0), "nsp": "/chat"}, connect)
+}
+
+func TestDecode(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	encode := func(p map[string]interface{}) frame {
+		b, err := mp.Marshal(p)
+		must.NoError(err)
+
+		return frame{ft: session.BINARY, data: b}
+	}
+
+	f := &fakeFrames{frames: []frame{
+		encode(map[string]interface{}{"type": 2, "data": []interface{}{"msg", "hello", []byte{1, 2}, map[string]interface{}{"a": 1}}, "nsp": "/chat", "id": 5}),
+		encode(map[string]interface{}{"type": 0, "data": map[string]interface{}{"token": "abc"}, "nsp": "/?x=1"}),
+		encode(map[string]interface{}{"type": 4, "data": "failed", "nsp": "/"}),
+		{ft: session.TEXT, data: []byte("2")},
+	}}
+	decoder := Parser.NewDecoder(f)
+
+	var header parser.Header
+	var event string
+	must.NoError(decoder.DecodeHeader(&header, &event))
+	should.Equal(parser.Header{Type: parser.Event, ID: 5, NeedAck: true, Namespace: "/chat"}, header)
+	should.Equal("msg", event)
+
+	var raw []json.RawMessage
+	args, rest, err := decoder.DecodeArgsValidated([]reflect.Type{reflect.TypeOf(""), reflect.TypeOf(&parser.Buffer{})}, func(args []json.RawMessage) error {
+		raw = args
+		return nil
+	})
+	must.NoError(err)
+	must.Len(args, 2)
+	should.Equal("hello", args[0].Interface())
+	should.Equal([]byte{1, 2}, args[1].Interface().(*parser.Buffer).Data)
+	should.Equal([]interface{}{map[string]interface{}{"a": int8(1)}}, rest)
+	should.Equal([]json.RawMessage{json.RawMessage(`"hello"`), json.RawMessage(`"AQI="`), json.RawMessage(`{"a":1}`)}, raw)
+
+	must.NoError(decoder.DecodeHeader(&header, &event))
+	should.Equal(parser.Header{Type: parser.Connect, Query: "x=1"}, header)
+
+	var auth map[string]interface{}
+	must.NoError(decoder.DecodeData(&auth))
+	should.Equal(map[string]interface{}{"token": "abc"}, auth)
+
+	must.NoError(decoder.DecodeHeader(&header, &event))
+	should.Equal(parser.Error, header.Type)
+
+	args, err = decoder.DecodeArgs([]reflect.Type{reflect.TypeOf("")})
+	must.NoError(err)
+	should.Equal("failed", args[0].Interface())
+
+	err = decoder.DecodeHeader(&header, &event)
+	should.True(errors.Is(err, errInvalidFrameType))
+}
@@ -35,4 +35,7 @@ type Payload struct {
 	Header Header
 
 	Data []interface{}
+
+	// Uncompressed asks the transport to send the packet uncompressed.
+	Uncompressed bool
 }
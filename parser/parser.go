@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Parser creates the encoder and the decoder of the packets of a connection.
+type Parser interface {
+	NewEncoder(w FrameWriter) PacketEncoder
+	NewDecoder(r FrameReader) PacketDecoder
+}
+
+// PacketEncoder encodes packets into frames.
+type PacketEncoder interface {
+	Encode(h Header, args ...interface{}) error
+	// EncodeUncompressed encodes like Encode, but asks the transport to
+	// send the frames of the packet uncompressed.
+	EncodeUncompressed(h Header, args ...interface{}) error
+}
+
+// PacketDecoder decodes packets from frames. DecodeHeader reads the header of
+// the next packet, then the packet is read by one of the other decoding
+// methods or discarded.
+type PacketDecoder interface {
+	DecodeHeader(header *Header, event *string) error
+	DecodeArgs(types []reflect.Type) ([]reflect.Value, error)
+	DecodeArgsWithRest(types []reflect.Type) ([]reflect.Value, []interface{}, error)
+	DecodeArgsValidated(types []reflect.Type, validate func(args []json.RawMessage) error) ([]reflect.Value, []interface{}, error)
+	DecodeData(v interface{}) error
+	DiscardLast() error
+	Close() error
+}
+
+// JSON is the default parser of socket.io, encoding packets as JSON text
+// frames followed by a binary frame for each Buffer.
+var JSON Parser = jsonParser{}
+
+type jsonParser struct{}
+
+func (jsonParser) NewEncoder(w FrameWriter) PacketEncoder {
+	return NewEncoder(w)
+}
+
+func (jsonParser) NewDecoder(r FrameReader) PacketDecoder {
+	return NewDecoder(r)
+}
@@ -0,0 +1,91 @@
+package socketio
+
+// presenceDiffEvent is the event emitted to a room when its presence changes.
+const presenceDiffEvent = "presence_diff"
+
+// PresenceMember is a connection tracked in a room together with its metadata.
+type PresenceMember struct {
+	ID   string                 `json:"id"`
+	Meta map[string]interface{} `json:"meta"`
+}
+
+// PresenceDiff is the payload of the presence_diff event. Updating the metadata
+// of a member is reported as a leave of the old metadata and a join of the new one.
+type PresenceDiff struct {
+	Room   string           `json:"room"`
+	Joins  []PresenceMember `json:"joins"`
+	Leaves []PresenceMember `json:"leaves"`
+}
+
+func (d PresenceDiff) empty() bool {
+	return len(d.Joins) == 0 && len(d.Leaves) == 0
+}
+
+// presenceRooms is a map of rooms where each room contains a map of connection id
+// to the presence of that connection. It is not safe for concurrent use, callers
+// guard it with the lock of the broadcast owning the rooms.
+type presenceRooms map[string]map[string]PresenceMember
+
+// set sets the presence of member in the room and returns the diff.
+func (p presenceRooms) set(room string, member PresenceMember) PresenceDiff {
+	diff := PresenceDiff{Room: room}
+
+	members, ok := p[room]
+	if !ok {
+		members = make(map[string]PresenceMember)
+		p[room] = members
+	}
+
+	if old, ok := members[member.ID]; ok {
+		diff.Leaves = append(diff.Leaves, old)
+	}
+
+	members[member.ID] = member
+	diff.Joins = append(diff.Joins, member)
+
+	return diff
+}
+
+// remove removes the presence of id from the room and returns the diff.
+func (p presenceRooms) remove(room, id string) PresenceDiff {
+	diff := PresenceDiff{Room: room}
+
+	members, ok := p[room]
+	if !ok {
+		return diff
+	}
+
+	if old, ok := members[id]; ok {
+		diff.Leaves = append(diff.Leaves, old)
+		delete(members, id)
+	}
+
+	if len(members) == 0 {
+		delete(p, room)
+	}
+
+	return diff
+}
+
+// removeAll removes the presence of id from all rooms and returns the diffs.
+func (p presenceRooms) removeAll(id string) []PresenceDiff {
+	var diffs []PresenceDiff
+
+	for room := range p {
+		if diff := p.remove(room, id); !diff.empty() {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs
+}
+
+// members gives the list of members in the room.
+func (p presenceRooms) members(room string) []PresenceMember {
+	members := make([]PresenceMember, 0, len(p[room]))
+	for _, member := range p[room] {
+		members = append(members, member)
+	}
+
+	return members
+}
@@ -0,0 +1,100 @@
+package socketio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type emitted struct {
+	event string
+	args  []interface{}
+}
+
+type fakeConn struct {
+	Conn
+
+	id      string
+	data    *Store
+	emitted []emitted
+}
+
+func (c *fakeConn) ID() string {
+	return c.id
+}
+
+func (c *fakeConn) Namespace() string {
+	return aliasRootNamespace
+}
+
+func (c *fakeConn) Handshake() Handshake {
+	return Handshake{}
+}
+
+func (c *fakeConn) Data() *Store {
+	if c.data == nil {
+		c.data = newStore()
+	}
+
+	return c.data
+}
+
+func (c *fakeConn) Emit(event string, args ...interface{}) {
+	c.emitted = append(c.emitted, emitted{event: event, args: args})
+}
+
+func TestBroadcastPresence(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	bc := newBroadcast("/", newTracer())
+
+	alice := &fakeConn{id: "alice"}
+	bob := &fakeConn{id: "bob"}
+
+	bc.JoinPresence("room", alice, map[string]interface{}{"status": "online"})
+	bc.JoinPresence("room", bob, map[string]interface{}{"status": "away"})
+
+	should.Equal(2, bc.Len("room"))
+	should.ElementsMatch([]PresenceMember{
+		{ID: "alice", Meta: map[string]interface{}{"status": "online"}},
+		{ID: "bob", Meta: map[string]interface{}{"status": "away"}},
+	}, bc.Presence("room"))
+
+	must.Len(alice.emitted, 2)
+	should.Equal(presenceDiffEvent, alice.emitted[1].event)
+	should.Equal(PresenceDiff{
+		Room:  "room",
+		Joins: []PresenceMember{{ID: "bob", Meta: map[string]interface{}{"status": "away"}}},
+	}, alice.emitted[1].args[0])
+
+	bc.JoinPresence("room", bob, map[string]interface{}{"status": "online"})
+
+	must.Len(alice.emitted, 3)
+	should.Equal(PresenceDiff{
+		Room:   "room",
+		Joins:  []PresenceMember{{ID: "bob", Meta: map[string]interface{}{"status": "online"}}},
+		Leaves: []PresenceMember{{ID: "bob", Meta: map[string]interface{}{"status": "away"}}},
+	}, alice.emitted[2].args[0])
+
+	bc.LeaveAll(bob)
+
+	should.Equal(1, bc.Len("room"))
+	should.Equal([]PresenceMember{{ID: "alice", Meta: map[string]interface{}{"status": "online"}}}, bc.Presence("room"))
+
+	must.Len(alice.emitted, 4)
+	should.Equal(PresenceDiff{
+		Room:   "room",
+		Leaves: []PresenceMember{{ID: "bob", Meta: map[string]interface{}{"status": "online"}}},
+	}, alice.emitted[3].args[0])
+
+	bc.Join("other", alice)
+	bc.Leave("other", alice)
+
+	should.Len(alice.emitted, 4)
+
+	bc.Clear("room")
+
+	should.Empty(bc.Presence("room"))
+}
@@ -0,0 +1,89 @@
+package socketio
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Events dispatched to the client handlers while reconnecting.
+const (
+	// reconnectAttemptEvent is dispatched with the attempt number before each
+	// attempt.
+	reconnectAttemptEvent = "reconnect_attempt"
+	// reconnectEvent is dispatched with the attempt number once reconnected.
+	reconnectEvent = "reconnect"
+	// reconnectFailedEvent is dispatched once all the attempts failed.
+	reconnectFailedEvent = "reconnect_failed"
+)
+
+// ReconnectionOptions is configuration of the reconnection of a Client whose
+// connection is lost.
+type ReconnectionOptions struct {
+	// Attempts is the number of attempts before giving up, unlimited if 0.
+	Attempts int
+	// Delay is the delay before the first attempt, 1 second by default. It
+	// doubles after each attempt.
+	Delay time.Duration
+	// MaxDelay is the maximal delay between attempts, 5 seconds by default.
+	MaxDelay time.Duration
+	// RandomizationFactor is the jitter of the delays, between 0 and 1, 0.5
+	// by default. Set it to a negative value to disable the jitter.
+	RandomizationFactor float64
+}
+
+func (o *ReconnectionOptions) getAttempts() int {
+	if o != nil && o.Attempts > 0 {
+		return o.Attempts
+	}
+	return 0
+}
+
+func (o *ReconnectionOptions) getDelay() time.Duration {
+	if o != nil && o.Delay > 0 {
+		return o.Delay
+	}
+	return time.Second
+}
+
+func (o *ReconnectionOptions) getMaxDelay() time.Duration {
+	if o != nil && o.MaxDelay > 0 {
+		return o.MaxDelay
+	}
+	return 5 * time.Second
+}
+
+func (o *ReconnectionOptions) getRandomizationFactor() float64 {
+	if o == nil || o.RandomizationFactor == 0 {
+		return 0.5
+	}
+	if o.RandomizationFactor < 0 {
+		return 0
+	}
+	return math.Min(o.RandomizationFactor, 1)
+}
+
+// backoff gives the delay before the attempt, starting at 1, like the backoff
+// of socket.io-client.
+func (o *ReconnectionOptions) backoff(attempt int) time.Duration {
+	maxDelay := float64(o.getMaxDelay())
+
+	// the delay doubles up to the max delay, not to overflow with the attempts
+	// of the unlimited reconnections.
+	delay := float64(o.getDelay()) * math.Pow(2, float64(attempt-1))
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	if factor := o.getRandomizationFactor(); factor > 0 {
+		r := rand.Float64()
+		deviation := math.Floor(r * factor * delay)
+		if int(math.Floor(r*10))&1 == 0 {
+			delay -= deviation
+		} else {
+			delay += deviation
+		}
+	}
+
+	return time.Duration(math.Min(delay, maxDelay))
+}
@@ -0,0 +1,30 @@
+package socketio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconnectionBackoff(t *testing.T) {
+	should := assert.New(t)
+
+	opts := &ReconnectionOptions{RandomizationFactor: -1}
+	should.Equal(time.Second, opts.backoff(1))
+	should.Equal(2*time.Second, opts.backoff(2))
+	should.Equal(4*time.Second, opts.backoff(3))
+	should.Equal(5*time.Second, opts.backoff(4))
+
+	opts = &ReconnectionOptions{Delay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for i := 0; i < 100; i++ {
+		delay := opts.backoff(2)
+		should.True(delay >= 100*time.Millisecond && delay <= 300*time.Millisecond, delay)
+		should.True(opts.backoff(10) <= time.Second)
+
+		// the delay of the unlimited attempts stays within the max delay.
+		delay = opts.backoff(math.MaxInt32)
+		should.True(delay >= 500*time.Millisecond && delay <= time.Second, delay)
+	}
+}
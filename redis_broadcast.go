@@ -1,13 +1,18 @@
 package socketio
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/googollee/go-socket.io/metrics"
 )
 
 // redisBroadcast gives Join, Leave & BroadcastTO server API support to socket.io along with room management
@@ -22,9 +27,22 @@ type redisBroadcast struct {
 	reqChannel string
 	resChannel string
 
-	requests map[string]interface{}
+	// requests are the requests waiting for the responses of the other
+	// nodes, by request id. They're answered on the subscriber goroutine.
+	requests        map[string]interface{}
+	requestsLock    sync.Mutex
+	requestsTimeout time.Duration
+
+	// ctx is done once the subscriber goroutine returns, and no response
+	// comes anymore.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	metrics metrics.Metrics
+	tracer  *tracer
 
-	rooms map[string]map[string]Conn
+	rooms    map[string]map[string]Conn
+	presence presenceRooms
 
 	lock sync.RWMutex
 }
@@ -34,6 +52,8 @@ const (
 	roomLenReqType   = "0"
 	clearRoomReqType = "1"
 	allRoomReqType   = "2"
+	presenceReqType  = "3"
+	socketsReqType   = "4"
 )
 
 // request structs
@@ -65,6 +85,28 @@ type allRoomRequest struct {
 	done        chan bool       `json:"-"`
 }
 
+type presenceRequest struct {
+	RequestType string
+	RequestID   string
+	Room        string
+	members     []PresenceMember `json:"-"`
+	numSub      int              `json:"-"`
+	msgCount    int              `json:"-"`
+	mutex       sync.Mutex       `json:"-"`
+	done        chan bool        `json:"-"`
+}
+
+type socketsRequest struct {
+	RequestType string
+	RequestID   string
+	Room        string
+	sockets     []RemoteSocket `json:"-"`
+	numSub      int            `json:"-"`
+	msgCount    int            `json:"-"`
+	mutex       sync.Mutex     `json:"-"`
+	done        chan bool      `json:"-"`
+}
+
 // response struct
 type roomLenResponse struct {
 	RequestType string
@@ -78,6 +120,18 @@ type allRoomResponse struct {
 	Rooms       []string
 }
 
+type presenceResponse struct {
+	RequestType string
+	RequestID   string
+	Members     []PresenceMember
+}
+
+type socketsResponse struct {
+	RequestType string
+	RequestID   string
+	Sockets     []RemoteSocket
+}
+
 func newRedisBroadcast(nsp string, opts *RedisAdapterOptions) (*redisBroadcast, error) {
 	addr := opts.getAddr()
 	var redisOpts []redis.DialOption
@@ -105,17 +159,34 @@ func newRedisBroadcast(nsp string, opts *RedisAdapterOptions) (*redisBroadcast,
 		return nil, err
 	}
 
+	m := opts.metrics
+	if m == nil {
+		m = metrics.Nop{}
+	}
+
+	t := opts.tracer
+	if t == nil {
+		t = newTracer()
+	}
+
 	uid := newV4UUID()
+	ctx, cancel := context.WithCancel(context.Background())
 	rbc := &redisBroadcast{
-		rooms:      make(map[string]map[string]Conn),
-		requests:   make(map[string]interface{}),
-		sub:        subConn,
-		pub:        pubConn,
-		key:        fmt.Sprintf("%s#%s#%s", opts.Prefix, nsp, uid),
-		reqChannel: fmt.Sprintf("%s-request#%s", opts.Prefix, nsp),
-		resChannel: fmt.Sprintf("%s-response#%s", opts.Prefix, nsp),
-		nsp:        nsp,
-		uid:        uid,
+		metrics:         m,
+		tracer:          t,
+		rooms:           make(map[string]map[string]Conn),
+		presence:        make(presenceRooms),
+		requests:        make(map[string]interface{}),
+		requestsTimeout: opts.getRequestsTimeout(),
+		ctx:             ctx,
+		cancel:          cancel,
+		sub:             subConn,
+		pub:             pubConn,
+		key:             fmt.Sprintf("%s#%s#%s", opts.Prefix, nsp, uid),
+		reqChannel:      fmt.Sprintf("%s-request#%s", opts.Prefix, nsp),
+		resChannel:      fmt.Sprintf("%s-response#%s", opts.Prefix, nsp),
+		nsp:             nsp,
+		uid:             uid,
 	}
 
 	if err = subConn.Subscribe(rbc.reqChannel, rbc.resChannel); err != nil {
@@ -140,20 +211,15 @@ func (bc *redisBroadcast) AllRooms() []string {
 	req.numSub = numSub
 	req.done = make(chan bool, 1)
 
-	bc.requests[req.RequestID] = &req
-	_, err := bc.pub.Conn.Do("PUBLISH", bc.reqChannel, reqJSON)
-	if err != nil {
+	if !bc.request(req.RequestID, &req, reqJSON, req.done) {
 		return []string{} // if error occurred,return empty
 	}
 
-	<-req.done
-
 	rooms := make([]string, 0, len(req.rooms))
 	for room := range req.rooms {
 		rooms = append(rooms, room)
 	}
 
-	delete(bc.requests, req.RequestID)
 	return rooms
 }
 
@@ -169,10 +235,26 @@ func (bc *redisBroadcast) Join(room string, connection Conn) {
 	bc.rooms[room][connection.ID()] = connection
 }
 
+// JoinPresence joins the given connection to the redisBroadcast room and tracks
+// its presence with meta. Members of the room on all nodes receive the presence diff.
+func (bc *redisBroadcast) JoinPresence(room string, connection Conn, meta map[string]interface{}) {
+	bc.lock.Lock()
+
+	if _, ok := bc.rooms[room]; !ok {
+		bc.rooms[room] = make(map[string]Conn)
+	}
+
+	bc.rooms[room][connection.ID()] = connection
+	diff := bc.presence.set(room, PresenceMember{ID: connection.ID(), Meta: meta})
+
+	bc.lock.Unlock()
+
+	bc.sendPresenceDiffs(diff)
+}
+
 // Leave leaves the given connection from given room (if exist)
 func (bc *redisBroadcast) Leave(room string, connection Conn) {
 	bc.lock.Lock()
-	defer bc.lock.Unlock()
 
 	if connections, ok := bc.rooms[room]; ok {
 		delete(connections, connection.ID())
@@ -181,12 +263,16 @@ func (bc *redisBroadcast) Leave(room string, connection Conn) {
 			delete(bc.rooms, room)
 		}
 	}
+	diff := bc.presence.remove(room, connection.ID())
+
+	bc.lock.Unlock()
+
+	bc.sendPresenceDiffs(diff)
 }
 
 // LeaveAll leaves the given connection from all rooms.
 func (bc *redisBroadcast) LeaveAll(connection Conn) {
 	bc.lock.Lock()
-	defer bc.lock.Unlock()
 
 	for room, connections := range bc.rooms {
 		delete(connections, connection.ID())
@@ -195,6 +281,11 @@ func (bc *redisBroadcast) LeaveAll(connection Conn) {
 			delete(bc.rooms, room)
 		}
 	}
+	diffs := bc.presence.removeAll(connection.ID())
+
+	bc.lock.Unlock()
+
+	bc.sendPresenceDiffs(diffs...)
 }
 
 // Clear clears the room.
@@ -203,11 +294,16 @@ func (bc *redisBroadcast) Clear(room string) {
 	defer bc.lock.Unlock()
 
 	delete(bc.rooms, room)
+	delete(bc.presence, room)
 	go bc.publishClear(room)
 }
 
 // Send sends given event & args to all the connections in the specified room.
-func (bc *redisBroadcast) Send(room, event string, args ...interface{}) {
+func (bc *redisBroadcast) Send(ctx context.Context, room, event string, args ...interface{}) {
+	ctx, span := bc.tracer.start(ctx, broadcastSpanName, trace.SpanKindProducer,
+		namespaceAttr.String(namespaceName(bc.nsp)), roomAttr.String(room), eventAttr.String(event))
+	defer span.End()
+
 	bc.lock.RLock()
 	defer bc.lock.RUnlock()
 
@@ -218,11 +314,15 @@ func (bc *redisBroadcast) Send(room, event string, args ...interface{}) {
 		}
 	}
 
-	bc.publishMessage(room, event, args...)
+	bc.publishMessage(ctx, room, event, args...)
 }
 
 // SendAll sends given event & args to all the connections to all the rooms.
-func (bc *redisBroadcast) SendAll(event string, args ...interface{}) {
+func (bc *redisBroadcast) SendAll(ctx context.Context, event string, args ...interface{}) {
+	ctx, span := bc.tracer.start(ctx, broadcastSpanName, trace.SpanKindProducer,
+		namespaceAttr.String(namespaceName(bc.nsp)), eventAttr.String(event))
+	defer span.End()
+
 	bc.lock.RLock()
 	defer bc.lock.RUnlock()
 
@@ -231,7 +331,7 @@ func (bc *redisBroadcast) SendAll(event string, args ...interface{}) {
 			connection.Emit(event, args...)
 		}
 	}
-	bc.publishMessage("", event, args...)
+	bc.publishMessage(ctx, "", event, args...)
 }
 
 // ForEach sends data returned by DataFunc, if room does not exits sends nothing.
@@ -271,18 +371,101 @@ func (bc *redisBroadcast) Len(room string) int {
 
 	req.done = make(chan bool, 1)
 
-	bc.requests[req.RequestID] = &req
-	_, err = bc.pub.Conn.Do("PUBLISH", bc.reqChannel, reqJSON)
-	if err != nil {
+	if !bc.request(req.RequestID, &req, reqJSON, req.done) {
 		return -1
 	}
 
-	<-req.done
-
-	delete(bc.requests, req.RequestID)
 	return req.connections
 }
 
+// Presence gives list of all the members tracked in the room on all nodes.
+func (bc *redisBroadcast) Presence(room string) []PresenceMember {
+	req := presenceRequest{
+		RequestType: presenceReqType,
+		RequestID:   newV4UUID(),
+		Room:        room,
+	}
+
+	reqJSON, err := json.Marshal(&req)
+	if err != nil {
+		return nil
+	}
+
+	numSub, err := bc.getNumSub(bc.reqChannel)
+	if err != nil {
+		return nil
+	}
+
+	req.numSub = numSub
+	req.done = make(chan bool, 1)
+
+	if !bc.request(req.RequestID, &req, reqJSON, req.done) {
+		return nil
+	}
+
+	return req.members
+}
+
+// FetchSockets gives snapshots of the connections in the room on all nodes, or
+// of all the connections if room is empty.
+func (bc *redisBroadcast) FetchSockets(room string) []RemoteSocket {
+	req := socketsRequest{
+		RequestType: socketsReqType,
+		RequestID:   newV4UUID(),
+		Room:        room,
+	}
+
+	reqJSON, err := json.Marshal(&req)
+	if err != nil {
+		return nil
+	}
+
+	numSub, err := bc.getNumSub(bc.reqChannel)
+	if err != nil {
+		return nil
+	}
+
+	req.numSub = numSub
+	req.done = make(chan bool, 1)
+
+	if !bc.request(req.RequestID, &req, reqJSON, req.done) {
+		return nil
+	}
+
+	return req.sockets
+}
+
+// request publishes the request req with id, and waits until done tells all
+// the nodes answered it. It gives false if the request isn't published or
+// isn't answered in time, and req mustn't be read then.
+func (bc *redisBroadcast) request(id string, req interface{}, reqJSON []byte, done chan bool) bool {
+	bc.requestsLock.Lock()
+	bc.requests[id] = req
+	bc.requestsLock.Unlock()
+
+	defer func() {
+		bc.requestsLock.Lock()
+		delete(bc.requests, id)
+		bc.requestsLock.Unlock()
+	}()
+
+	if _, err := bc.pub.Conn.Do("PUBLISH", bc.reqChannel, reqJSON); err != nil {
+		return false
+	}
+
+	timer := time.NewTimer(bc.requestsTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	case <-bc.ctx.Done():
+		return false
+	}
+}
+
 // Rooms gives the list of all the rooms available for redisBroadcast in case of
 // no connection is given, in case of a connection is given, it gives
 // list of all the rooms the connection is joined to.
@@ -328,10 +511,28 @@ func (bc *redisBroadcast) onMessage(channel string, msg []byte) error {
 		return errors.New("invalid event")
 	}
 
+	// the publish time is missing in messages of older nodes.
+	if len(opts) > 2 {
+		if published, ok := opts[2].(float64); ok {
+			bc.metrics.AdapterReceived(namespaceName(bc.nsp), time.Since(time.Unix(0, int64(published))))
+		}
+	}
+
+	ctx := context.Background()
+	if carriers := bcMessage["trace"]; len(carriers) > 0 {
+		if carrier, ok := carriers[0].(map[string]interface{}); ok {
+			ctx = bc.tracer.extract(carrier)
+		}
+	}
+
+	ctx, span := bc.tracer.start(ctx, receiveSpanName, trace.SpanKindConsumer,
+		namespaceAttr.String(namespaceName(bc.nsp)), roomAttr.String(room), eventAttr.String(event))
+	defer span.End()
+
 	if room != "" {
-		bc.send(room, event, args...)
+		bc.send(ctx, room, event, args...)
 	} else {
-		bc.sendAll(event, args...)
+		bc.sendAll(ctx, event, args...)
 	}
 
 	return nil
@@ -377,6 +578,22 @@ func (bc *redisBroadcast) onRequest(msg []byte) {
 		}
 		bc.publish(bc.resChannel, &res)
 
+	case presenceReqType:
+		res := presenceResponse{
+			RequestType: req["RequestType"],
+			RequestID:   req["RequestID"],
+			Members:     bc.presenceMembers(req["Room"]),
+		}
+		bc.publish(bc.resChannel, &res)
+
+	case socketsReqType:
+		res := socketsResponse{
+			RequestType: req["RequestType"],
+			RequestID:   req["RequestID"],
+			Sockets:     bc.localSockets(req["Room"]),
+		}
+		bc.publish(bc.resChannel, &res)
+
 	case clearRoomReqType:
 		if bc.uid == req["UUID"] {
 			return
@@ -408,7 +625,12 @@ func (bc *redisBroadcast) onResponse(msg []byte) {
 		return
 	}
 
-	req, ok := bc.requests[res["RequestID"].(string)]
+	id, _ := res["RequestID"].(string)
+
+	bc.requestsLock.Lock()
+	req, ok := bc.requests[id]
+	bc.requestsLock.Unlock()
+
 	if !ok {
 		return
 	}
@@ -445,6 +667,40 @@ func (bc *redisBroadcast) onResponse(msg []byte) {
 			allRoomReq.done <- true
 		}
 
+	case presenceReqType:
+		presenceReq := req.(*presenceRequest)
+
+		var presenceRes presenceResponse
+		if err := json.Unmarshal(msg, &presenceRes); err != nil {
+			return
+		}
+
+		presenceReq.mutex.Lock()
+		presenceReq.msgCount++
+		presenceReq.members = append(presenceReq.members, presenceRes.Members...)
+		presenceReq.mutex.Unlock()
+
+		if presenceReq.numSub == presenceReq.msgCount {
+			presenceReq.done <- true
+		}
+
+	case socketsReqType:
+		socketsReq := req.(*socketsRequest)
+
+		var socketsRes socketsResponse
+		if err := json.Unmarshal(msg, &socketsRes); err != nil {
+			return
+		}
+
+		socketsReq.mutex.Lock()
+		socketsReq.msgCount++
+		socketsReq.sockets = append(socketsReq.sockets, socketsRes.Sockets...)
+		socketsReq.mutex.Unlock()
+
+		if socketsReq.numSub == socketsReq.msgCount {
+			socketsReq.done <- true
+		}
+
 	default:
 	}
 }
@@ -465,9 +721,16 @@ func (bc *redisBroadcast) clear(room string) {
 	defer bc.lock.Unlock()
 
 	delete(bc.rooms, room)
+	delete(bc.presence, room)
 }
 
-func (bc *redisBroadcast) send(room string, event string, args ...interface{}) {
+// send sends the event received from another node to the connections in the
+// room, traced as a child of the receive span ctx.
+func (bc *redisBroadcast) send(ctx context.Context, room string, event string, args ...interface{}) {
+	_, span := bc.tracer.start(ctx, broadcastSpanName, trace.SpanKindProducer,
+		namespaceAttr.String(namespaceName(bc.nsp)), roomAttr.String(room), eventAttr.String(event))
+	defer span.End()
+
 	bc.lock.RLock()
 	defer bc.lock.RUnlock()
 
@@ -481,27 +744,45 @@ func (bc *redisBroadcast) send(room string, event string, args ...interface{}) {
 	}
 }
 
-func (bc *redisBroadcast) publishMessage(room string, event string, args ...interface{}) {
-	opts := make([]interface{}, 2)
+func (bc *redisBroadcast) publishMessage(ctx context.Context, room string, event string, args ...interface{}) {
+	start := time.Now()
+
+	ctx, span := bc.tracer.start(ctx, publishSpanName, trace.SpanKindProducer,
+		namespaceAttr.String(namespaceName(bc.nsp)), roomAttr.String(room), eventAttr.String(event))
+
+	opts := make([]interface{}, 3)
 	opts[0] = room
 	opts[1] = event
+	opts[2] = start.UnixNano()
 
 	bcMessage := map[string][]interface{}{
-		"opts": opts,
-		"args": args,
+		"opts":  opts,
+		"args":  args,
+		"trace": {bc.tracer.inject(ctx)},
 	}
 	bcMessageJSON, err := json.Marshal(bcMessage)
 	if err != nil {
+		endSpan(span, err)
 		return
 	}
 
 	_, err = bc.pub.Conn.Do("PUBLISH", bc.key, bcMessageJSON)
 	if err != nil {
+		endSpan(span, err)
 		return
 	}
+
+	span.End()
+	bc.metrics.AdapterPublished(namespaceName(bc.nsp), time.Since(start))
 }
 
-func (bc *redisBroadcast) sendAll(event string, args ...interface{}) {
+// sendAll sends the event received from another node to all the connections,
+// traced as a child of the receive span ctx.
+func (bc *redisBroadcast) sendAll(ctx context.Context, event string, args ...interface{}) {
+	_, span := bc.tracer.start(ctx, broadcastSpanName, trace.SpanKindProducer,
+		namespaceAttr.String(namespaceName(bc.nsp)), eventAttr.String(event))
+	defer span.End()
+
 	bc.lock.RLock()
 	defer bc.lock.RUnlock()
 
@@ -524,6 +805,28 @@ func (bc *redisBroadcast) allRooms() []string {
 	return rooms
 }
 
+func (bc *redisBroadcast) presenceMembers(room string) []PresenceMember {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+
+	return bc.presence.members(room)
+}
+
+func (bc *redisBroadcast) localSockets(room string) []RemoteSocket {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+
+	return fetchSockets(bc.rooms, room)
+}
+
+func (bc *redisBroadcast) sendPresenceDiffs(diffs ...PresenceDiff) {
+	for _, diff := range diffs {
+		if !diff.empty() {
+			bc.Send(context.Background(), diff.Room, presenceDiffEvent, diff)
+		}
+	}
+}
+
 func (bc *redisBroadcast) getRoomsByConn(connection Conn) []string {
 	var rooms []string
 
@@ -537,6 +840,8 @@ func (bc *redisBroadcast) getRoomsByConn(connection Conn) []string {
 }
 
 func (bc *redisBroadcast) dispatch() {
+	defer bc.cancel()
+
 	for {
 		switch m := bc.sub.Receive().(type) {
 		case redis.Message:
@@ -0,0 +1,96 @@
+package socketio
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedisConn is a redis.Conn with numSub subscribers, which gives the
+// messages published to published.
+type fakeRedisConn struct {
+	redis.Conn
+
+	numSub    int64
+	published chan []byte
+}
+
+func (c *fakeRedisConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	switch cmd {
+	case "PUBSUB":
+		return []interface{}{args[1], c.numSub}, nil
+	case "PUBLISH":
+		c.published <- args[1].([]byte)
+	}
+
+	return nil, nil
+}
+
+func newFakeRedisBroadcast(numSub int64, timeout time.Duration) (*redisBroadcast, *fakeRedisConn) {
+	conn := &fakeRedisConn{numSub: numSub, published: make(chan []byte, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &redisBroadcast{
+		pub:             &redis.PubSubConn{Conn: conn},
+		requests:        make(map[string]interface{}),
+		requestsTimeout: timeout,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, conn
+}
+
+func TestRedisBroadcastRequest(t *testing.T) {
+	should := assert.New(t)
+
+	bc, conn := newFakeRedisBroadcast(2, time.Second)
+
+	go func() {
+		var req presenceRequest
+		should.NoError(json.Unmarshal(<-conn.published, &req))
+
+		for _, id := range []string{"a", "b"} {
+			res, err := json.Marshal(presenceResponse{
+				RequestType: req.RequestType,
+				RequestID:   req.RequestID,
+				Members:     []PresenceMember{{ID: id}},
+			})
+			should.NoError(err)
+			bc.onResponse(res)
+		}
+	}()
+
+	should.ElementsMatch([]PresenceMember{{ID: "a"}, {ID: "b"}}, bc.Presence("room"))
+	should.Empty(bc.requests)
+}
+
+func TestRedisBroadcastRequestTimeout(t *testing.T) {
+	should := assert.New(t)
+
+	bc, conn := newFakeRedisBroadcast(2, 10*time.Millisecond)
+
+	// a node doesn't answer.
+	go func() {
+		var req socketsRequest
+		_ = json.Unmarshal(<-conn.published, &req)
+
+		res, _ := json.Marshal(socketsResponse{RequestType: req.RequestType, RequestID: req.RequestID})
+		bc.onResponse(res)
+	}()
+
+	should.Nil(bc.FetchSockets(""))
+	should.Empty(bc.requests)
+
+	// no response comes once the subscriber is gone.
+	bc, conn = newFakeRedisBroadcast(1, time.Minute)
+	go func() {
+		<-conn.published
+		bc.cancel()
+	}()
+
+	should.Equal(-1, bc.Len("room"))
+	should.Empty(bc.requests)
+}
@@ -0,0 +1,279 @@
+package socketio
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/googollee/go-socket.io/logger"
+)
+
+// Reasons of the emits dropped from the send buffer.
+var (
+	ErrSendBufferFull    = errors.New("send buffer full")
+	ErrSendBufferExpired = errors.New("buffered emit expired")
+)
+
+// SendBufferOptions is configuration of the buffer of the emits of sockets
+// which aren't connected. The buffered emits of a namespace are sent in order
+// once it's connected.
+type SendBufferOptions struct {
+	// Size is the maximal number of buffered emits, 100 by default. The oldest
+	// emit is dropped once the buffer is full.
+	Size int
+	// Expiry is how long each emit is kept in the buffer, forever if 0.
+	Expiry time.Duration
+	// Path is the file the buffer is persisted to, and loaded from by the next
+	// manager with the same Path. The args are persisted as JSON, and the
+	// emits whose args aren't JSON, like acks or streams, are only kept in
+	// memory. The buffer isn't persisted if Path is empty.
+	Path string
+	// OnDrop is called with the emits dropped from the buffer, and the reason,
+	// ErrSendBufferFull or ErrSendBufferExpired.
+	OnDrop func(namespace, event string, args []interface{}, err error)
+}
+
+func (o *SendBufferOptions) getSize() int {
+	if o != nil && o.Size > 0 {
+		return o.Size
+	}
+	return 100
+}
+
+func (o *SendBufferOptions) getExpiry() time.Duration {
+	if o != nil && o.Expiry > 0 {
+		return o.Expiry
+	}
+	return 0
+}
+
+// bufferedEmit is an emit in the send buffer.
+type bufferedEmit struct {
+	Namespace string        `json:"namespace"`
+	Event     string        `json:"event"`
+	Args      []interface{} `json:"args"`
+	// Expires is the expiry in unix milliseconds, or 0.
+	Expires int64 `json:"expires,omitempty"`
+}
+
+func (e bufferedEmit) expired(now time.Time) bool {
+	return e.Expires != 0 && now.UnixMilli() >= e.Expires
+}
+
+type sendBuffer struct {
+	opts   *SendBufferOptions
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	emits    []bufferedEmit
+	flushing map[string]bool
+}
+
+// newSendBuffer returns the buffer with opts, with the emits persisted to the
+// Path of opts.
+func newSendBuffer(opts *SendBufferOptions, l *slog.Logger) (*sendBuffer, error) {
+	b := &sendBuffer{
+		opts:     opts,
+		logger:   l,
+		flushing: make(map[string]bool),
+	}
+
+	if opts.Path == "" {
+		return b, nil
+	}
+
+	data, err := os.ReadFile(opts.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &b.emits); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// push buffers the emit of event with args to namespace.
+func (b *sendBuffer) push(namespace, event string, args []interface{}) {
+	emit := bufferedEmit{
+		Namespace: namespace,
+		Event:     event,
+		Args:      args,
+	}
+	if expiry := b.opts.getExpiry(); expiry > 0 {
+		emit.Expires = time.Now().Add(expiry).UnixMilli()
+	}
+
+	b.mu.Lock()
+
+	dropped := b.prune(time.Now())
+
+	if len(b.emits) >= b.opts.getSize() {
+		dropped = append(dropped, droppedEmit{b.emits[0], ErrSendBufferFull})
+		b.emits = b.emits[1:]
+	}
+	b.emits = append(b.emits, emit)
+	b.save()
+
+	b.mu.Unlock()
+
+	b.drop(dropped)
+}
+
+// pending tells if namespace has buffered emits, or is flushing them.
+func (b *sendBuffer) pending(namespace string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.flushing[namespace] {
+		return true
+	}
+
+	for _, emit := range b.emits {
+		if emit.Namespace == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// flush sends the buffered emits of namespace in order with nc, until none is
+// left. The emits buffered while flushing are sent by the same flush.
+func (b *sendBuffer) flush(nc *namespaceConn) {
+	namespace := fmtNS(nc.namespace)
+
+	b.mu.Lock()
+	if b.flushing[namespace] {
+		b.mu.Unlock()
+		return
+	}
+	b.flushing[namespace] = true
+	b.mu.Unlock()
+
+	for {
+		emit, ok := b.next(namespace)
+		if !ok {
+			return
+		}
+
+		nc.Emit(emit.Event, emit.Args...)
+	}
+}
+
+// next removes the next emit of namespace from the buffer, or stops the
+// flushing of namespace if there is none.
+func (b *sendBuffer) next(namespace string) (bufferedEmit, bool) {
+	b.mu.Lock()
+
+	dropped := b.prune(time.Now())
+
+	var emit bufferedEmit
+	found := false
+	for i := range b.emits {
+		if b.emits[i].Namespace == namespace {
+			emit = b.emits[i]
+			b.emits = append(b.emits[:i:i], b.emits[i+1:]...)
+			found = true
+			break
+		}
+	}
+
+	if found || len(dropped) > 0 {
+		b.save()
+	}
+	if !found {
+		delete(b.flushing, namespace)
+	}
+
+	b.mu.Unlock()
+
+	b.drop(dropped)
+
+	return emit, found
+}
+
+type droppedEmit struct {
+	bufferedEmit
+
+	err error
+}
+
+// prune removes the expired emits. The caller must hold mu.
+func (b *sendBuffer) prune(now time.Time) []droppedEmit {
+	var dropped []droppedEmit
+
+	kept := b.emits[:0]
+	for _, emit := range b.emits {
+		if emit.expired(now) {
+			dropped = append(dropped, droppedEmit{emit, ErrSendBufferExpired})
+			continue
+		}
+		kept = append(kept, emit)
+	}
+	b.emits = kept
+
+	return dropped
+}
+
+func (b *sendBuffer) drop(dropped []droppedEmit) {
+	for _, emit := range dropped {
+		b.logger.Info("drop buffered emit", logger.NamespaceKey, namespaceName(emit.Namespace), logger.EventKey, emit.Event, logger.Err(emit.err))
+
+		if b.opts.OnDrop != nil {
+			b.opts.OnDrop(emit.Namespace, emit.Event, emit.Args, emit.err)
+		}
+
+		failAck(emit.Args, emit.err)
+	}
+}
+
+// save persists the emits whose args are JSON to the file of the buffer. The
+// caller must hold mu.
+func (b *sendBuffer) save() {
+	if b.opts.Path == "" {
+		return
+	}
+
+	emits := make([]json.RawMessage, 0, len(b.emits))
+	for _, emit := range b.emits {
+		data, err := json.Marshal(emit)
+		if err != nil {
+			continue
+		}
+		emits = append(emits, data)
+	}
+
+	data, err := json.Marshal(emits)
+	if err != nil {
+		b.logger.Error("marshal send buffer", logger.Err(err))
+		return
+	}
+
+	// write then rename, not to leave a partial file.
+	tmp, err := os.CreateTemp(filepath.Dir(b.opts.Path), filepath.Base(b.opts.Path)+".*")
+	if err != nil {
+		b.logger.Error("persist send buffer", logger.Err(err))
+		return
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), b.opts.Path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		b.logger.Error("persist send buffer", logger.Err(err))
+	}
+}
@@ -0,0 +1,121 @@
+package socketio
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/googollee/go-socket.io/logger"
+)
+
+func TestSendBuffer(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	var dropped []string
+	opts := &SendBufferOptions{
+		Size: 2,
+		Path: filepath.Join(t.TempDir(), "buffer.json"),
+		OnDrop: func(namespace, event string, _ []interface{}, err error) {
+			should.Equal(ErrSendBufferFull, err)
+			dropped = append(dropped, namespace+" "+event)
+		},
+	}
+
+	b, err := newSendBuffer(opts, logger.Log)
+	must.NoError(err)
+
+	b.push("/files", "first", []interface{}{1})
+	b.push("/chat", "second", []interface{}{"a"})
+	b.push("/files", "third", []interface{}{"b", 2})
+
+	should.Equal([]string{"/files first"}, dropped)
+	should.True(b.pending("/files"))
+	should.False(b.pending("/admin"))
+
+	// a new manager sends the persisted emits.
+	b, err = newSendBuffer(opts, logger.Log)
+	must.NoError(err)
+
+	c, nc := newStreamConn()
+	defer close(c.quitChan)
+
+	go b.flush(nc)
+
+	pkg := nextPayload(t, c)
+	should.Equal([]interface{}{"third", "b", float64(2)}, pkg.Data)
+	should.True(b.pending("/chat"))
+
+	select {
+	case pkg := <-c.writeChan:
+		must.FailNow("emit of other namespace", pkg.Data[0])
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSendBufferExpiry(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	expired := make(chan string, 1)
+	b, err := newSendBuffer(&SendBufferOptions{
+		Expiry: time.Millisecond,
+		OnDrop: func(_, event string, _ []interface{}, err error) {
+			should.Equal(ErrSendBufferExpired, err)
+			expired <- event
+		},
+	}, logger.Log)
+	must.NoError(err)
+
+	b.push("/files", "late", nil)
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok := b.next("/files")
+	should.False(ok)
+	should.Equal("late", <-expired)
+	should.False(b.pending("/files"))
+}
+
+func TestClientSendBuffer(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	srv := NewServer(nil)
+
+	received := make(chan string, 2)
+	srv.OnConnect("/", func(Conn) error { return nil })
+	srv.OnConnect("/chat", func(Conn) error { return nil })
+	srv.OnEvent("/chat", "msg", func(_ Conn, msg string) {
+		received <- msg
+	})
+
+	go func() {
+		_ = srv.Serve()
+	}()
+
+	hs := httptest.NewServer(srv)
+	defer hs.Close()
+	defer srv.Close()
+
+	client, err := NewClient(hs.URL+"/chat", &ClientOptions{EIO: 3, SendBuffer: &SendBufferOptions{}})
+	must.NoError(err)
+	defer client.Close()
+
+	client.Emit("msg", "first")
+	client.Emit("msg", "second")
+
+	must.NoError(client.Connect())
+
+	for _, msg := range []string{"first", "second"} {
+		select {
+		case got := <-received:
+			should.Equal(msg, got)
+		case <-time.After(5 * time.Second):
+			must.FailNow("buffered emit not sent")
+		}
+	}
+}
@@ -1,13 +1,16 @@
 package socketio
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/gomodule/redigo/redis"
 
 	"github.com/googollee/go-socket.io/engineio"
 	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/metrics"
 	"github.com/googollee/go-socket.io/parser"
 )
 
@@ -16,18 +19,51 @@ type Server struct {
 	engine *engineio.Server
 
 	handlers *namespaceHandlers
+	parser   parser.Parser
 
 	redisAdapter *RedisAdapterOptions
+
+	metrics metrics.Metrics
+	tracer  *tracer
+	logger  *slog.Logger
+	admin   *admin
 }
 
-// NewServer returns a server.
+// NewServer returns a server. The server reports to the Metrics and logs to
+// the Logger of opts.
 func NewServer(opts *engineio.Options) *Server {
+	engine := engineio.NewServer(opts)
+
 	return &Server{
 		handlers: newNamespaceHandlers(),
-		engine:   engineio.NewServer(opts),
+		parser:   parser.JSON,
+		engine:   engine,
+		metrics:  engine.Metrics(),
+		tracer:   newTracer(),
+		logger:   engine.Logger(),
 	}
 }
 
+// SetLogger sets the logger of socket.io connections, with the session ID,
+// transport, namespace and event as attributes. The engine.io sessions keep
+// the Logger of the options. It must be called before serving connections.
+func (s *Server) SetLogger(l *slog.Logger) {
+	s.logger = l
+}
+
+// SetParser sets the parser of socket.io packets, parser.JSON by default. The
+// clients must use the same parser. It must be called before serving
+// connections.
+func (s *Server) SetParser(p parser.Parser) {
+	s.parser = p
+}
+
+// Tracing enables OpenTelemetry tracing of events, acks and broadcasts with
+// opts. It must be called before serving connections.
+func (s *Server) Tracing(opts *TracingOptions) {
+	s.tracer.configure(opts)
+}
+
 // Adapter sets redis broadcast adapter.
 func (s *Server) Adapter(opts *RedisAdapterOptions) (bool, error) {
 	opts = getOptions(opts)
@@ -44,13 +80,39 @@ func (s *Server) Adapter(opts *RedisAdapterOptions) (bool, error) {
 		return false, err
 	}
 
+	opts.metrics = s.metrics
+	opts.tracer = s.tracer
 	s.redisAdapter = opts
 
 	return true, conn.Close()
 }
 
+// Admin serves the Socket.IO Admin UI protocol on the namespace of opts. The
+// admin UI gets the stats and sockets of all the servers sharing the adapter,
+// so Adapter must be called first. Join, leave and disconnect actions apply to
+// the sockets of this server. It must be called before serving connections.
+// The admin UI is refused without the credentials of opts, unless
+// AllowUnauthenticated is set.
+func (s *Server) Admin(opts *AdminOptions) {
+	s.admin = newAdmin(s, opts)
+
+	handler := s.createNamespace(s.admin.namespace)
+	handler.OnConnect(s.admin.onConnect)
+	handler.afterConnect = s.admin.afterConnect
+
+	if !s.admin.readOnly {
+		handler.OnEvent("join", s.admin.join)
+		handler.OnEvent("leave", s.admin.leave)
+		handler.OnEvent("_disconnect", s.admin.disconnect)
+	}
+
+	go s.admin.serveStats()
+}
+
 // Close closes server.
 func (s *Server) Close() error {
+	s.admin.close()
+
 	return s.engine.Close()
 }
 
@@ -99,6 +161,19 @@ func (s *Server) OnEvent(namespace, event string, f interface{}) {
 	h.OnEvent(event, f)
 }
 
+// OnEventValidator sets a validator v of the args of event for namespace.
+// Events with invalid args don't reach the handler: the client gets a
+// ValidationError as the ack of the event, or with the validation_error event
+// if it doesn't wait for an ack.
+func (s *Server) OnEventValidator(namespace, event string, v Validator) {
+	h := s.getNamespace(namespace)
+	if h == nil {
+		h = s.createNamespace(namespace)
+	}
+
+	h.OnEventValidator(event, v)
+}
+
 // Serve serves go-socket.io server.
 func (s *Server) Serve() error {
 	for {
@@ -158,9 +233,15 @@ func (s *Server) ClearRoom(namespace string, room string) bool {
 
 // BroadcastToRoom broadcasts given event & args to all the connections in the room.
 func (s *Server) BroadcastToRoom(namespace string, room, event string, args ...interface{}) bool {
+	return s.BroadcastToRoomContext(context.Background(), namespace, room, event, args...)
+}
+
+// BroadcastToRoomContext is BroadcastToRoom traced as a child of ctx, like the
+// TraceContext of the event being handled.
+func (s *Server) BroadcastToRoomContext(ctx context.Context, namespace string, room, event string, args ...interface{}) bool {
 	nspHandler := s.getNamespace(namespace)
 	if nspHandler != nil {
-		nspHandler.broadcast.Send(room, event, args...)
+		nspHandler.broadcast.Send(ctx, room, event, args...)
 		return true
 	}
 
@@ -169,9 +250,15 @@ func (s *Server) BroadcastToRoom(namespace string, room, event string, args ...i
 
 // BroadcastToNamespace broadcasts given event & args to all the connections in the same namespace.
 func (s *Server) BroadcastToNamespace(namespace string, event string, args ...interface{}) bool {
+	return s.BroadcastToNamespaceContext(context.Background(), namespace, event, args...)
+}
+
+// BroadcastToNamespaceContext is BroadcastToNamespace traced as a child of ctx,
+// like the TraceContext of the event being handled.
+func (s *Server) BroadcastToNamespaceContext(ctx context.Context, namespace string, event string, args ...interface{}) bool {
 	nspHandler := s.getNamespace(namespace)
 	if nspHandler != nil {
-		nspHandler.broadcast.SendAll(event, args...)
+		nspHandler.broadcast.SendAll(ctx, event, args...)
 		return true
 	}
 
@@ -188,6 +275,28 @@ func (s *Server) RoomLen(namespace string, room string) int {
 	return -1
 }
 
+// RoomPresence gives list of all the members tracked in the room with their metadata.
+func (s *Server) RoomPresence(namespace string, room string) []PresenceMember {
+	nspHandler := s.getNamespace(namespace)
+	if nspHandler != nil {
+		return nspHandler.broadcast.Presence(room)
+	}
+
+	return nil
+}
+
+// FetchSockets gives snapshots of the connections in the room, or of all the
+// connections in the namespace if room is empty. With the redis adapter it
+// includes the connections served by other nodes.
+func (s *Server) FetchSockets(namespace string, room string) []RemoteSocket {
+	nspHandler := s.getNamespace(namespace)
+	if nspHandler != nil {
+		return nspHandler.broadcast.FetchSockets(room)
+	}
+
+	return nil
+}
+
 // Rooms gives list of all the rooms.
 func (s *Server) Rooms(namespace string) []string {
 	nspHandler := s.getNamespace(namespace)
@@ -220,7 +329,8 @@ func (s *Server) ForEach(namespace string, room string, f EachFunc) bool {
 }
 
 func (s *Server) serveConn(conn engineio.Conn) {
-	c := newConn(conn, s.handlers)
+	c := newConn(conn, s.handlers, s.parser, s.metrics, s.tracer, s.logger)
+	c.admin = s.admin
 	if err := c.connect(); err != nil {
 		_ = c.Close()
 		if root, ok := s.handlers.Get(rootNamespace); ok && root.onError != nil {
@@ -238,7 +348,7 @@ func (s *Server) serveConn(conn engineio.Conn) {
 func (s *Server) serveError(c *conn) {
 	defer func() {
 		if err := c.Close(); err != nil {
-			logger.Error("close connect:", err)
+			c.logger.Error("close connect", logger.Err(err))
 		}
 
 		s.engine.Remove(c.Conn.ID())
@@ -270,7 +380,7 @@ func (s *Server) serveError(c *conn) {
 func (s *Server) serveWrite(c *conn) {
 	defer func() {
 		if err := c.Close(); err != nil {
-			logger.Error("close connect:", err)
+			c.logger.Error("close connect", logger.Err(err))
 		}
 
 		s.engine.Remove(c.Conn.ID())
@@ -281,7 +391,7 @@ func (s *Server) serveWrite(c *conn) {
 		case <-c.quitChan:
 			return
 		case pkg := <-c.writeChan:
-			if err := c.encoder.Encode(pkg.Header, pkg.Data); err != nil {
+			if err := c.encode(pkg); err != nil {
 				c.onError(pkg.Header.Namespace, err)
 			}
 		}
@@ -289,9 +399,11 @@ func (s *Server) serveWrite(c *conn) {
 }
 
 func (s *Server) serveRead(c *conn) {
+	reason := clientDisconnectMsg
+
 	defer func() {
-		if err := c.Close(); err != nil {
-			logger.Error("close connect:", err)
+		if err := c.closeWith(reason); err != nil {
+			c.logger.Error("close connect", logger.Err(err))
 		}
 
 		s.engine.Remove(c.Conn.ID())
@@ -303,7 +415,13 @@ func (s *Server) serveRead(c *conn) {
 		var header parser.Header
 
 		if err := c.decoder.DecodeHeader(&header, &event); err != nil {
-			logger.Error("DecodeHeader Error in serveRead", err)
+			// the read deadline of the session expires without the pings.
+			var timeout interface{ Timeout() bool }
+			if errors.As(err, &timeout) && timeout.Timeout() {
+				reason = pingTimeoutMsg
+			}
+
+			c.log(rootNamespace).Error("decode header", logger.Err(err))
 			c.onError(rootNamespace, err)
 			return
 		}
@@ -325,7 +443,7 @@ func (s *Server) serveRead(c *conn) {
 		}
 
 		if err != nil {
-			logger.Error("serve read:", err)
+			c.log(header.Namespace).Error("serve read", logger.Err(err))
 
 			return
 		}
@@ -337,7 +455,7 @@ func (s *Server) createNamespace(nsp string) *namespaceHandler {
 		nsp = rootNamespace
 	}
 
-	handler := newNamespaceHandler(nsp, s.redisAdapter)
+	handler := newNamespaceHandler(nsp, s.tracer, s.redisAdapter)
 	s.handlers.Set(nsp, handler)
 
 	return handler
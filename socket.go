@@ -0,0 +1,216 @@
+package socketio
+
+import (
+	"sync"
+	"time"
+
+	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/parser"
+)
+
+// Socket is the client of a namespace, sharing the connection of its Manager
+// with the other namespaces.
+type Socket struct {
+	manager   *Manager
+	namespace string
+
+	mu     sync.Mutex
+	auth   interface{}
+	active bool
+}
+
+// Namespace returns the namespace of the socket.
+func (s *Socket) Namespace() string {
+	return s.namespace
+}
+
+// SetAuth sets the auth payload sent to the server with the connect packet of
+// the namespace, which the server gets with Handshake, instead of the Auth of
+// the options. It's sent again when the manager reconnects.
+func (s *Socket) SetAuth(auth interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.auth = auth
+}
+
+func (s *Socket) getAuth() interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.auth != nil {
+		return s.auth
+	}
+
+	return s.manager.opts.getAuth()
+}
+
+func (s *Socket) isActive() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.active
+}
+
+func (s *Socket) setActive(active bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active = active
+}
+
+// Connect connects the namespace, and the manager if it isn't connected.
+func (s *Socket) Connect() error {
+	s.setActive(true)
+
+	return s.manager.connect(s)
+}
+
+// Disconnect disconnects the namespace, keeping the connection of the manager
+// for the other namespaces.
+func (s *Socket) Disconnect() error {
+	s.setActive(false)
+
+	conn := s.manager.getConn()
+	if conn == nil {
+		return nil
+	}
+
+	nc, ok := conn.namespaces.Get(s.namespace)
+	if !ok {
+		return nil
+	}
+
+	header := parser.Header{
+		Type:      parser.Disconnect,
+		Namespace: s.namespace,
+	}
+	if s.namespace == rootNamespace {
+		header.Namespace = ""
+	}
+	conn.write(header)
+
+	nc.failAcks(ErrAckDisconnected)
+	nc.LeaveAll()
+	conn.namespaces.Delete(s.namespace)
+
+	if handler := s.manager.getNamespace(s.namespace); handler != nil && handler.onDisconnect != nil {
+		handler.onDisconnect(nc, clientDisconnectMsg)
+	}
+
+	return nil
+}
+
+// Connected tells if the namespace is connected.
+func (s *Socket) Connected() bool {
+	_, ok := s.namespaceConn()
+	return ok
+}
+
+// Emit emits the event with args to the namespace. The emits of a socket which
+// isn't connected are buffered with the SendBuffer of the options, and
+// dropped without it.
+func (s *Socket) Emit(event string, args ...interface{}) {
+	nc, connected := s.namespaceConn()
+	buffer := s.manager.buffer
+
+	if connected && (buffer == nil || !buffer.pending(s.namespace)) {
+		nc.Emit(event, args...)
+		return
+	}
+
+	if buffer == nil {
+		s.manager.logger.Info("connection namespace not initialized", logger.NamespaceKey, namespaceName(s.namespace), logger.EventKey, event)
+		failAck(args, ErrAckDisconnected)
+		return
+	}
+
+	buffer.push(s.namespace, event, args)
+
+	// the emits buffered while connected are sent after the pending ones.
+	if connected {
+		go buffer.flush(nc)
+	}
+}
+
+// EmitWithAck emits the event with args to the namespace, and calls ack with
+// the args of the ack of the server. ack is a func whose first param is an
+// error, like func(err error, reply string), which is ErrAckTimeout if the
+// server doesn't ack within timeout, unless timeout is 0, or
+// ErrAckDisconnected if the connection is lost before. ack is called once. A
+// variadic ack like func(err error, args ...interface{}) gets all the args.
+func (s *Socket) EmitWithAck(timeout time.Duration, event string, ack interface{}, args ...interface{}) {
+	s.Emit(event, append(args, newAckWaiter(ack, timeout))...)
+}
+
+// namespaceConn returns the conn of the namespace, if it's connected.
+func (s *Socket) namespaceConn() (*namespaceConn, bool) {
+	conn := s.manager.getConn()
+	if conn == nil || !conn.alive() {
+		return nil, false
+	}
+
+	return conn.namespaces.Get(s.namespace)
+}
+
+// OnConnect set a handler function f to handle open event for namespace.
+func (s *Socket) OnConnect(f func(Conn) error) {
+	s.handler().OnConnect(f)
+}
+
+// OnDisconnect set a handler function f to handle disconnect event for namespace.
+func (s *Socket) OnDisconnect(f func(Conn, string)) {
+	s.handler().OnDisconnect(f)
+}
+
+// OnError set a handler function f to handle error for namespace.
+func (s *Socket) OnError(f func(Conn, error)) {
+	s.handler().OnError(f)
+}
+
+// OnEvent set a handler function f to handle event for namespace.
+func (s *Socket) OnEvent(event string, f interface{}) {
+	s.handler().OnEvent(event, f)
+}
+
+// Once set a handler function f to handle event for namespace, which is
+// removed once called.
+func (s *Socket) Once(event string, f interface{}) {
+	s.handler().OnceEvent(event, f)
+}
+
+// Off removes the handler of event for namespace.
+func (s *Socket) Off(event string) {
+	s.handler().OffEvent(event)
+}
+
+// OnAny set a handler function f to be called with each event received by
+// namespace, before the handler of the event.
+func (s *Socket) OnAny(f func(conn Conn, event string, args []interface{})) {
+	s.handler().OnAny(f)
+}
+
+// OffAny removes the handler of OnAny.
+func (s *Socket) OffAny() {
+	s.handler().OnAny(nil)
+}
+
+// OnAnyOutgoing set a handler function f to be called with each event emitted
+// to namespace, without the ack.
+func (s *Socket) OnAnyOutgoing(f func(conn Conn, event string, args []interface{})) {
+	s.handler().OnAnyOutgoing(f)
+}
+
+// OffAnyOutgoing removes the handler of OnAnyOutgoing.
+func (s *Socket) OffAnyOutgoing() {
+	s.handler().OnAnyOutgoing(nil)
+}
+
+func (s *Socket) handler() *namespaceHandler {
+	h := s.manager.getNamespace(s.namespace)
+	if h == nil {
+		h = s.manager.createNamespace(s.namespace)
+	}
+
+	return h
+}
@@ -0,0 +1,259 @@
+package socketiotest
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// Event is an event received by a client. Its args are decoded from JSON,
+// like float64 for the numbers.
+type Event struct {
+	Name string
+	Args []interface{}
+}
+
+// Client is a client of a namespace of a Server. Its methods fail the test
+// if the server doesn't answer within the Timeout of the server.
+type Client struct {
+	t       testing.TB
+	timeout time.Duration
+	manager *socketio.Manager
+	socket  *socketio.Socket
+
+	mu     sync.Mutex
+	id     string
+	events []Event
+	reason string
+	// changed is closed, and replaced, once an event is received or the
+	// client is disconnected.
+	changed chan struct{}
+}
+
+// Connect connects a client to the namespace, once the server accepts it. The
+// transports of opts are replaced by the in-memory one. The client is closed
+// once the test ends.
+func (s *Server) Connect(namespace string, opts *socketio.ClientOptions) *Client {
+	s.t.Helper()
+
+	var o socketio.ClientOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Transports = []string{s.transport.Name()}
+	o.CustomTransports = append(o.CustomTransports, s.transport)
+	// the server speaks engine.io v3.
+	o.EIO = 3
+
+	m, err := socketio.NewManager("http://socketiotest", &o)
+	if err != nil {
+		s.t.Fatalf("new manager: %s", err)
+	}
+	s.t.Cleanup(func() {
+		_ = m.Close()
+	})
+
+	c := &Client{
+		t:       s.t,
+		timeout: s.timeout(),
+		manager: m,
+		socket:  m.Socket(namespace),
+		changed: make(chan struct{}),
+	}
+
+	connected := make(chan error, 1)
+	report := func(err error) {
+		select {
+		case connected <- err:
+		default:
+		}
+	}
+
+	c.socket.OnConnect(func(conn socketio.Conn) error {
+		c.update(func() {
+			c.id = conn.ID()
+			c.reason = ""
+		})
+		report(nil)
+		return nil
+	})
+	c.socket.OnError(func(_ socketio.Conn, err error) {
+		report(err)
+	})
+	c.socket.OnDisconnect(func(_ socketio.Conn, reason string) {
+		c.update(func() {
+			c.reason = reason
+		})
+	})
+	c.socket.OnAny(func(_ socketio.Conn, event string, args []interface{}) {
+		c.update(func() {
+			c.events = append(c.events, Event{Name: event, Args: args})
+		})
+	})
+
+	if err := c.socket.Connect(); err != nil {
+		s.t.Fatalf("connect %s: %s", namespace, err)
+	}
+
+	select {
+	case err := <-connected:
+		if err != nil {
+			s.t.Fatalf("connect %s: %s", namespace, err)
+		}
+	case <-time.After(c.timeout):
+		s.t.Fatalf("connect %s: not connected after %s", namespace, c.timeout)
+	}
+
+	return c
+}
+
+// update updates the client with f, waking the waiting methods.
+func (c *Client) update(f func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f()
+
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// ID returns the ID of the connection of the client on the server.
+func (c *Client) ID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.id
+}
+
+// Socket returns the socket of the client, whose OnAny, OnConnect,
+// OnDisconnect and OnError are set by the client.
+func (c *Client) Socket() *socketio.Socket {
+	return c.socket
+}
+
+// Emit emits the event with args to the server.
+func (c *Client) Emit(event string, args ...interface{}) {
+	c.socket.Emit(event, args...)
+}
+
+// EmitWithAck emits the event with args to the server, and returns the args
+// of its ack.
+func (c *Client) EmitWithAck(event string, args ...interface{}) []interface{} {
+	c.t.Helper()
+
+	type ack struct {
+		err  error
+		args []interface{}
+	}
+
+	acks := make(chan ack, 1)
+	c.socket.EmitWithAck(c.timeout, event, func(err error, args ...interface{}) {
+		acks <- ack{err, args}
+	}, args...)
+
+	a := <-acks
+	if a.err != nil {
+		c.t.Fatalf("ack of %s: %s", event, a.err)
+	}
+
+	return a.args
+}
+
+// AssertAck asserts that the server acks the event emitted with args with
+// want, and tells if it does.
+func (c *Client) AssertAck(want []interface{}, event string, args ...interface{}) bool {
+	c.t.Helper()
+
+	got := c.EmitWithAck(event, args...)
+	if len(got) == 0 && len(want) == 0 {
+		return true
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		c.t.Errorf("ack of %s: got %#v, want %#v", event, got, want)
+		return false
+	}
+
+	return true
+}
+
+// Events returns the events received by the client, which aren't taken by
+// WaitEvent.
+func (c *Client) Events() []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Event(nil), c.events...)
+}
+
+// WaitEvent waits for the event, and returns its args. The event received
+// first is taken, and the others are kept.
+func (c *Client) WaitEvent(event string) []interface{} {
+	c.t.Helper()
+
+	timeout := time.After(c.timeout)
+
+	for {
+		c.mu.Lock()
+		for i, e := range c.events {
+			if e.Name == event {
+				c.events = append(c.events[:i], c.events[i+1:]...)
+				c.mu.Unlock()
+
+				return e.Args
+			}
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timeout:
+			c.t.Fatalf("event %s not received after %s", event, c.timeout)
+			return nil
+		}
+	}
+}
+
+// WaitDisconnect waits for the client to be disconnected, and returns the
+// reason.
+func (c *Client) WaitDisconnect() string {
+	c.t.Helper()
+
+	timeout := time.After(c.timeout)
+
+	for {
+		c.mu.Lock()
+		reason, changed := c.reason, c.changed
+		c.mu.Unlock()
+
+		if reason != "" {
+			return reason
+		}
+
+		select {
+		case <-changed:
+		case <-timeout:
+			c.t.Fatalf("not disconnected after %s", c.timeout)
+			return ""
+		}
+	}
+}
+
+// Disconnect disconnects the namespace of the client.
+func (c *Client) Disconnect() {
+	c.t.Helper()
+
+	if err := c.socket.Disconnect(); err != nil {
+		c.t.Fatalf("disconnect: %s", err)
+	}
+}
+
+// Close closes the connection of the client.
+func (c *Client) Close() {
+	_ = c.manager.Close()
+}
@@ -0,0 +1,90 @@
+package socketiotest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the fake clock of the deadlines of the in-memory connections. It
+// runs with the time, and Advance moves it forward, expiring the deadlines
+// like the ones of the ping timeouts without waiting for them.
+type Clock struct {
+	mu     sync.Mutex
+	offset time.Duration
+	timers map[*timer]struct{}
+}
+
+type timer struct {
+	at    time.Time
+	f     func()
+	timer *time.Timer
+}
+
+// Now returns the time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return time.Now().Add(c.offset)
+}
+
+// AfterFunc calls f in its own goroutine once d elapses on the clock, unless
+// stop is called before.
+func (c *Clock) AfterFunc(d time.Duration, f func()) (stop func() bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.timers == nil {
+		c.timers = make(map[*timer]struct{})
+	}
+
+	t := &timer{
+		at: time.Now().Add(c.offset + d),
+		f:  f,
+	}
+	t.timer = time.AfterFunc(d, func() {
+		if c.remove(t) {
+			f()
+		}
+	})
+	c.timers[t] = struct{}{}
+
+	return func() bool {
+		t.timer.Stop()
+		return c.remove(t)
+	}
+}
+
+// remove removes t, and tells if it wasn't fired or stopped.
+func (c *Clock) remove(t *timer) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.timers[t]; !ok {
+		return false
+	}
+
+	delete(c.timers, t)
+	return true
+}
+
+// Advance moves the clock forward by d, firing the timers due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.offset += d
+	now := time.Now().Add(c.offset)
+
+	var due []*timer
+	for t := range c.timers {
+		if !t.at.After(now) {
+			due = append(due, t)
+			delete(c.timers, t)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, t := range due {
+		t.timer.Stop()
+		go t.f()
+	}
+}
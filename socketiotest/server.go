@@ -0,0 +1,105 @@
+// Package socketiotest tests the handlers of a socket.io server with clients
+// connected through an in-memory transport, without HTTP.
+//
+//	srv := socketiotest.NewServer(t, nil)
+//	srv.OnConnect("/", func(socketio.Conn) error { return nil })
+//	srv.OnEvent("/", "echo", func(_ socketio.Conn, msg string) string { return msg })
+//
+//	client := srv.Connect("/", nil)
+//	reply := client.EmitWithAck("echo", "hello")
+package socketiotest
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	socketio "github.com/googollee/go-socket.io"
+	"github.com/googollee/go-socket.io/engineio"
+	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/engineio/transport/memory"
+)
+
+// DefaultTimeout is how long the clients wait for the server by default.
+const DefaultTimeout = 5 * time.Second
+
+// Server is a socket.io server whose clients connect through an in-memory
+// transport. Its handlers are set like the ones of socketio.Server, before
+// connecting the clients.
+type Server struct {
+	*socketio.Server
+
+	// Clock is the clock of the deadlines of the connections, which drives
+	// the ping timeouts.
+	Clock *Clock
+	// Timeout is how long the clients wait for the server, DefaultTimeout by
+	// default.
+	Timeout time.Duration
+
+	t         testing.TB
+	transport *memory.Transport
+}
+
+// NewServer returns a server configured with opts, whose transports are
+// replaced by the in-memory one. It's closed once the test ends.
+func NewServer(t testing.TB, opts *engineio.Options) *Server {
+	t.Helper()
+
+	clock := &Clock{}
+	tr := &memory.Transport{Clock: clock}
+
+	var o engineio.Options
+	if opts != nil {
+		o = *opts
+	}
+	o.Transports = []transport.Transport{tr}
+
+	srv := socketio.NewServer(&o)
+	tr.Handler = srv
+
+	go func() {
+		_ = srv.Serve()
+	}()
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	return &Server{
+		Server:    srv,
+		Clock:     clock,
+		t:         t,
+		transport: tr,
+	}
+}
+
+func (s *Server) timeout() time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+	return DefaultTimeout
+}
+
+// Members returns the sorted IDs of the connections in the room of the
+// namespace.
+func (s *Server) Members(namespace, room string) []string {
+	var ids []string
+
+	s.ForEach(namespace, room, func(c socketio.Conn) {
+		ids = append(ids, c.ID())
+	})
+
+	sort.Strings(ids)
+	return ids
+}
+
+// InRoom tells if the client is in the room of its namespace.
+func (s *Server) InRoom(c *Client, room string) bool {
+	for _, id := range s.Members(c.socket.Namespace(), room) {
+		if id == c.ID() {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,100 @@
+package socketiotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	socketio "github.com/googollee/go-socket.io"
+	"github.com/googollee/go-socket.io/engineio"
+)
+
+func TestServer(t *testing.T) {
+	should := assert.New(t)
+
+	srv := NewServer(t, nil)
+
+	srv.OnConnect("/", func(socketio.Conn) error { return nil })
+	srv.OnEvent("/", "echo", func(_ socketio.Conn, msg string) string {
+		return msg
+	})
+	srv.OnEvent("/", "join", func(s socketio.Conn, room string) {
+		s.Join(room)
+		srv.BroadcastToRoom("/", room, "joined", s.ID())
+	})
+
+	alice := srv.Connect("/", nil)
+	bob := srv.Connect("/", nil)
+	should.NotEqual(alice.ID(), bob.ID())
+
+	alice.AssertAck([]interface{}{"hello"}, "echo", "hello")
+
+	alice.Emit("join", "lobby")
+	should.Equal([]interface{}{alice.ID()}, alice.WaitEvent("joined"))
+	should.True(srv.InRoom(alice, "lobby"))
+	should.False(srv.InRoom(bob, "lobby"))
+
+	bob.Emit("join", "lobby")
+	should.Equal([]interface{}{bob.ID()}, alice.WaitEvent("joined"))
+	should.Equal([]interface{}{bob.ID()}, bob.WaitEvent("joined"))
+	should.Len(srv.Members("/", "lobby"), 2)
+	should.Empty(alice.Events())
+
+	bob.Disconnect()
+	should.Equal("client namespace disconnect", bob.WaitDisconnect())
+}
+
+func TestServerPingTimeout(t *testing.T) {
+	should := assert.New(t)
+
+	srv := NewServer(t, &engineio.Options{
+		PingInterval: time.Minute,
+		PingTimeout:  time.Minute,
+	})
+
+	reasons := make(chan string, 1)
+	srv.OnConnect("/", func(socketio.Conn) error { return nil })
+	srv.OnDisconnect("/", func(_ socketio.Conn, reason string) {
+		reasons <- reason
+	})
+
+	client := srv.Connect("/", nil)
+
+	// the client doesn't ping before the deadline of the server expires.
+	srv.Clock.Advance(time.Minute + time.Second)
+
+	select {
+	case reason := <-reasons:
+		should.Equal("ping timeout", reason)
+	case <-time.After(time.Second):
+		t.Fatal("not disconnected by the server")
+	}
+
+	should.NotEmpty(client.WaitDisconnect())
+}
+
+func TestClock(t *testing.T) {
+	should := assert.New(t)
+
+	var clock Clock
+	start := clock.Now()
+
+	fired := make(chan struct{})
+	clock.AfterFunc(time.Hour, func() {
+		close(fired)
+	})
+	stopped := clock.AfterFunc(time.Hour, func() {
+		t.Error("stopped timer fired")
+	})
+	should.True(stopped())
+
+	clock.Advance(time.Hour)
+	should.True(clock.Now().Sub(start) >= time.Hour)
+
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("timer not fired")
+	}
+}
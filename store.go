@@ -0,0 +1,115 @@
+package socketio
+
+import "sync"
+
+// Store is a key/value store attached to a connection. Unlike Context, it is
+// safe for concurrent use, so it can be accessed outside of the handlers of
+// the connection.
+type Store struct {
+	values map[string]interface{}
+	mu     sync.RWMutex
+}
+
+func newStore() *Store {
+	return &Store{
+		values: make(map[string]interface{}),
+	}
+}
+
+// Get returns the value stored for key.
+func (s *Store) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set stores the value for key.
+func (s *Store) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[key] = value
+}
+
+// Delete deletes the value for key.
+func (s *Store) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.values, key)
+}
+
+// Update replaces the value for key with the result of f atomically. ok
+// reports whether the key was present when f is called.
+func (s *Store) Update(key string, f func(value interface{}, ok bool) interface{}) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.values[key]
+	value = f(value, ok)
+	s.values[key] = value
+
+	return value
+}
+
+// Len gives number of keys in the store.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.values)
+}
+
+// Range calls f for each key and value in the store, until f returns false.
+// f must not modify the store.
+func (s *Store) Range(f func(key string, value interface{}) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for key, value := range s.values {
+		if !f(key, value) {
+			return
+		}
+	}
+}
+
+// Snapshot returns a copy of all the keys and values in the store.
+func (s *Store) Snapshot() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := make(map[string]interface{}, len(s.values))
+	for key, value := range s.values {
+		ret[key] = value
+	}
+
+	return ret
+}
+
+// GetData returns the value stored for key in s as T. ok is false if there is
+// no value for key or the value is not a T.
+func GetData[T any](s *Store, key string) (value T, ok bool) {
+	raw, found := s.Get(key)
+	if !found {
+		return value, false
+	}
+
+	value, ok = raw.(T)
+	return value, ok
+}
+
+// UpdateData replaces the value stored for key in s with the result of f
+// atomically. f gets the zero value of T if there is no value for key or the
+// value is not a T.
+func UpdateData[T any](s *Store, key string, f func(value T) T) T {
+	var ret T
+	s.Update(key, func(raw interface{}, _ bool) interface{} {
+		value, _ := raw.(T)
+		ret = f(value)
+		return ret
+	})
+
+	return ret
+}
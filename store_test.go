@@ -0,0 +1,82 @@
+package socketio
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	should := assert.New(t)
+
+	s := newStore()
+
+	_, ok := s.Get("user")
+	should.False(ok)
+
+	s.Set("user", "alice")
+	s.Set("visits", 1)
+
+	user, ok := GetData[string](s, "user")
+	should.True(ok)
+	should.Equal("alice", user)
+
+	_, ok = GetData[int](s, "user")
+	should.False(ok)
+
+	should.Equal(2, s.Len())
+	should.Equal(map[string]interface{}{"user": "alice", "visits": 1}, s.Snapshot())
+
+	s.Delete("user")
+	_, ok = s.Get("user")
+	should.False(ok)
+}
+
+func TestStoreConcurrentUpdate(t *testing.T) {
+	should := assert.New(t)
+
+	s := newStore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			UpdateData(s, "counter", func(v int) int {
+				return v + 1
+			})
+		}()
+	}
+	wg.Wait()
+
+	counter, ok := GetData[int](s, "counter")
+	should.True(ok)
+	should.Equal(100, counter)
+}
+
+func TestBroadcastFetchSockets(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	bc := newBroadcast("/", newTracer())
+
+	alice := &fakeConn{id: "alice"}
+	alice.Data().Set("status", "online")
+	bob := &fakeConn{id: "bob"}
+
+	bc.Join("alice", alice)
+	bc.Join("room", alice)
+	bc.Join("bob", bob)
+
+	sockets := bc.FetchSockets("room")
+	must.Len(sockets, 1)
+	should.Equal("alice", sockets[0].ID)
+	should.ElementsMatch([]string{"alice", "room"}, sockets[0].Rooms)
+	should.Equal(map[string]interface{}{"status": "online"}, sockets[0].Data)
+
+	should.Len(bc.FetchSockets(""), 2)
+	should.Empty(bc.FetchSockets("not_exist"))
+}
@@ -0,0 +1,374 @@
+package socketio
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/parser"
+)
+
+// A reader emitted with Stream as an event arg is replaced by a streamRef, and
+// its data is sent in chunks with the stream events. The receiver grants the
+// sender a chunk each time it reads one, so at most streamWindow chunks are in
+// flight, and cancels the stream when the reader is closed before its end.
+const (
+	streamEventPrefix = "$stream:"
+
+	// streamChunkEvent sends the id and a parser.Buffer with the next chunk.
+	streamChunkEvent = streamEventPrefix + "chunk"
+	// streamEndEvent sends the id and the error of the reader, empty at
+	// io.EOF.
+	streamEndEvent = streamEventPrefix + "end"
+	// streamReadEvent grants the number of chunks of the id to the sender.
+	streamReadEvent = streamEventPrefix + "read"
+	// streamCancelEvent stops the sender of the id.
+	streamCancelEvent = streamEventPrefix + "cancel"
+
+	streamChunkSize = 64 * 1024
+	streamWindow    = 16
+)
+
+var (
+	readerType    = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	streamRefType = reflect.TypeOf(streamRef{})
+
+	streamIDType = reflect.TypeOf(uint64(0))
+
+	streamEventTypes = map[string][]reflect.Type{
+		streamChunkEvent:  {streamIDType, reflect.TypeOf(parser.Buffer{})},
+		streamEndEvent:    {streamIDType, reflect.TypeOf("")},
+		streamReadEvent:   {streamIDType, reflect.TypeOf(0)},
+		streamCancelEvent: {streamIDType},
+	}
+
+	errStreamClosed = errors.New("stream closed")
+	errStreamWindow = errors.New("stream chunk beyond window")
+)
+
+// Stream marks r to be streamed as an arg of Emit. Its data is sent in chunks
+// to the handler param of type io.Reader, and r is closed at its end if it is
+// an io.Closer. The other readers emitted are encoded like any value.
+//
+// The handlers with an io.Reader param are called in their own goroutine, not
+// to block the chunks, so they run concurrently with the other handlers of the
+// connection: the context of the connection must be locked if they access it,
+// and TraceContext doesn't give their event. The readers are closed once the
+// handler returns, which cancels the streams not read to the end.
+func Stream(r io.Reader) io.Reader {
+	return streamArg{Reader: r}
+}
+
+// streamArg is a reader marked by Stream.
+type streamArg struct {
+	io.Reader
+}
+
+// streamRef is sent in place of a streamed arg.
+type streamRef struct {
+	Stream bool   `json:"_stream" msgpack:"_stream"`
+	ID     uint64 `json:"id" msgpack:"id"`
+}
+
+// streams are the streams sent and received by a connection.
+type streams struct {
+	nextID uint64
+
+	mu  sync.Mutex
+	out map[uint64]*outStream
+	in  map[uint64]*streamReader
+}
+
+func newStreams() *streams {
+	return &streams{
+		out: make(map[uint64]*outStream),
+		in:  make(map[uint64]*streamReader),
+	}
+}
+
+type outStream struct {
+	r io.Reader
+
+	credit     chan struct{}
+	canceled   chan struct{}
+	cancelOnce sync.Once
+}
+
+func (o *outStream) cancel() {
+	o.cancelOnce.Do(func() {
+		close(o.canceled)
+	})
+}
+
+// open registers r to be sent by send, and gives its ref.
+func (s *streams) open(r io.Reader) streamRef {
+	id := atomic.AddUint64(&s.nextID, 1)
+
+	out := &outStream{
+		r:        r,
+		credit:   make(chan struct{}, streamWindow),
+		canceled: make(chan struct{}),
+	}
+	for i := 0; i < streamWindow; i++ {
+		out.credit <- struct{}{}
+	}
+
+	s.mu.Lock()
+	s.out[id] = out
+	s.mu.Unlock()
+
+	return streamRef{Stream: true, ID: id}
+}
+
+// send sends the data of the stream of ref, once the event with ref is
+// written.
+func (s *streams) send(nc *namespaceConn, ref streamRef) {
+	s.mu.Lock()
+	out := s.out[ref.ID]
+	s.mu.Unlock()
+
+	if out == nil {
+		return
+	}
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.out, ref.ID)
+		s.mu.Unlock()
+
+		if closer, ok := out.r.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				nc.conn.log(nc.namespace).Info("close streamed reader", logger.Err(err))
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-out.credit:
+		case <-out.canceled:
+			return
+		case <-nc.conn.quitChan:
+			return
+		}
+
+		chunk := make([]byte, streamChunkSize)
+		n, err := io.ReadFull(out.r, chunk)
+		if n > 0 {
+			nc.emitStream(streamChunkEvent, ref.ID, &parser.Buffer{Data: chunk[:n]})
+		}
+
+		switch err {
+		case nil:
+			continue
+		case io.EOF, io.ErrUnexpectedEOF:
+			nc.emitStream(streamEndEvent, ref.ID, "")
+		default:
+			nc.emitStream(streamEndEvent, ref.ID, err.Error())
+		}
+
+		return
+	}
+}
+
+// accept registers the stream of ref sent to nc, and gives its reader.
+func (s *streams) accept(nc *namespaceConn, ref streamRef) *streamReader {
+	r := &streamReader{
+		nc:     nc,
+		id:     ref.ID,
+		chunks: make(chan []byte, streamWindow),
+		closed: make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.in[ref.ID] = r
+	s.mu.Unlock()
+
+	return r
+}
+
+// handle handles the stream event of nc.
+func (s *streams) handle(nc *namespaceConn, event string, args []reflect.Value) {
+	id := args[0].Interface().(uint64)
+
+	switch event {
+	case streamChunkEvent:
+		s.mu.Lock()
+		r := s.in[id]
+		s.mu.Unlock()
+
+		if r == nil {
+			// nobody reads the stream.
+			nc.emitStream(streamCancelEvent, id)
+			return
+		}
+
+		select {
+		case r.chunks <- args[1].Interface().(parser.Buffer).Data:
+		default:
+			// the sender doesn't respect the window, and the data of the
+			// stream would miss the chunk.
+			s.mu.Lock()
+			delete(s.in, id)
+			s.mu.Unlock()
+
+			r.err = errStreamWindow
+			close(r.chunks)
+			nc.emitStream(streamCancelEvent, id)
+		}
+
+	case streamEndEvent:
+		s.mu.Lock()
+		r := s.in[id]
+		delete(s.in, id)
+		s.mu.Unlock()
+
+		if r == nil {
+			return
+		}
+
+		r.err = io.EOF
+		if msg := args[1].Interface().(string); msg != "" {
+			r.err = errors.New(msg)
+		}
+		close(r.chunks)
+
+	case streamReadEvent:
+		s.mu.Lock()
+		out := s.out[id]
+		s.mu.Unlock()
+
+		if out == nil {
+			return
+		}
+
+		for n := args[1].Interface().(int); n > 0; n-- {
+			select {
+			case out.credit <- struct{}{}:
+			default:
+			}
+		}
+
+	case streamCancelEvent:
+		s.mu.Lock()
+		out := s.out[id]
+		s.mu.Unlock()
+
+		if out != nil {
+			out.cancel()
+		}
+	}
+}
+
+// streamReader reads a stream sent to the connection. It is given to the
+// handlers with an io.Reader arg, and implements io.ReadCloser: closing it
+// before the end cancels the stream.
+type streamReader struct {
+	nc *namespaceConn
+	id uint64
+
+	// chunks is closed at the end of the stream, after err is set.
+	chunks chan []byte
+	err    error
+	buf    []byte
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		select {
+		case chunk, ok := <-r.chunks:
+			if !ok {
+				return 0, r.err
+			}
+			r.buf = chunk
+			r.nc.emitStream(streamReadEvent, r.id, 1)
+		case <-r.closed:
+			return 0, errStreamClosed
+		case <-r.nc.conn.quitChan:
+			return 0, io.ErrUnexpectedEOF
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
+
+func (r *streamReader) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.closed)
+
+		s := r.nc.conn.streams
+
+		s.mu.Lock()
+		_, reading := s.in[r.id]
+		delete(s.in, r.id)
+		s.mu.Unlock()
+
+		if reading {
+			r.nc.emitStream(streamCancelEvent, r.id)
+		}
+	})
+
+	return nil
+}
+
+// hasReader tells if the types of handler args have an io.Reader.
+func hasReader(types []reflect.Type) bool {
+	for _, typ := range types {
+		if typ == readerType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// streamTypes gives the types to decode the args of types, with a streamRef
+// for each io.Reader.
+func streamTypes(types []reflect.Type) []reflect.Type {
+	if !hasReader(types) {
+		return types
+	}
+
+	ret := make([]reflect.Type, len(types))
+	for i, typ := range types {
+		ret[i] = typ
+		if typ == readerType {
+			ret[i] = streamRefType
+		}
+	}
+
+	return ret
+}
+
+// acceptStreams replaces the streamRef args decoded for the io.Reader types
+// with the readers of their streams, or nil readers for args which aren't
+// streams. It gives the readers of the streams.
+func (s *streams) acceptStreams(nc *namespaceConn, types []reflect.Type, args []reflect.Value) []*streamReader {
+	var readers []*streamReader
+	for i, typ := range types {
+		if typ != readerType {
+			continue
+		}
+
+		ref := args[i].Interface().(streamRef)
+		if !ref.Stream {
+			args[i] = reflect.Zero(readerType)
+			continue
+		}
+
+		r := s.accept(nc, ref)
+		readers = append(readers, r)
+		args[i] = reflect.ValueOf(r)
+	}
+
+	return readers
+}
@@ -0,0 +1,231 @@
+package socketio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/metrics"
+	"github.com/googollee/go-socket.io/parser"
+)
+
+type closeReader struct {
+	io.Reader
+
+	closed chan struct{}
+}
+
+func (r *closeReader) Close() error {
+	close(r.closed)
+	return nil
+}
+
+func newStreamConn() (*conn, *namespaceConn) {
+	c := newConn(&fakeEngineConn{id: "sid"}, newNamespaceHandlers(), parser.JSON, metrics.Nop{}, newTracer(), logger.Log)
+	nc := newNamespaceConn(c, "/files", nil)
+
+	return c, nc
+}
+
+func nextPayload(t *testing.T, c *conn) parser.Payload {
+	select {
+	case pkg := <-c.writeChan:
+		return pkg
+	case <-time.After(time.Second):
+		require.FailNow(t, "no packet written")
+	}
+
+	return parser.Payload{}
+}
+
+func TestStreamSend(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	c, nc := newStreamConn()
+	defer close(c.quitChan)
+
+	r := &closeReader{
+		Reader: bytes.NewReader(make([]byte, (streamWindow+1)*streamChunkSize)),
+		closed: make(chan struct{}),
+	}
+	go nc.Emit("upload", "name", Stream(r))
+
+	pkg := nextPayload(t, c)
+	should.Equal([]interface{}{"upload", "name", streamRef{Stream: true, ID: 1}}, pkg.Data)
+
+	for i := 0; i < streamWindow; i++ {
+		pkg = nextPayload(t, c)
+		must.Equal(streamChunkEvent, pkg.Data[0])
+		should.Equal(uint64(1), pkg.Data[1])
+		should.Len(pkg.Data[2].(*parser.Buffer).Data, streamChunkSize)
+	}
+
+	select {
+	case <-c.writeChan:
+		must.FailNow("chunk beyond the window")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	c.streams.handle(nc, streamReadEvent, []reflect.Value{reflect.ValueOf(uint64(1)), reflect.ValueOf(1)})
+
+	pkg = nextPayload(t, c)
+	should.Equal(streamChunkEvent, pkg.Data[0])
+
+	c.streams.handle(nc, streamCancelEvent, []reflect.Value{reflect.ValueOf(uint64(1))})
+
+	select {
+	case <-r.closed:
+	case pkg := <-c.writeChan:
+		must.FailNow("packet after cancel", pkg.Data[0])
+	case <-time.After(time.Second):
+		must.FailNow("reader not closed")
+	}
+}
+
+func TestStreamReceive(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	c, nc := newStreamConn()
+	defer close(c.quitChan)
+
+	args := []reflect.Value{reflect.ValueOf("name"), reflect.ValueOf(streamRef{Stream: true, ID: 7})}
+	c.streams.acceptStreams(nc, []reflect.Type{reflect.TypeOf(""), readerType}, args)
+
+	r, ok := args[1].Interface().(io.ReadCloser)
+	must.True(ok)
+
+	id := reflect.ValueOf(uint64(7))
+	c.streams.handle(nc, streamChunkEvent, []reflect.Value{id, reflect.ValueOf(parser.Buffer{Data: []byte("hello ")})})
+	c.streams.handle(nc, streamChunkEvent, []reflect.Value{id, reflect.ValueOf(parser.Buffer{Data: []byte("world")})})
+	c.streams.handle(nc, streamEndEvent, []reflect.Value{id, reflect.ValueOf("")})
+
+	read := make(chan []byte)
+	go func() {
+		b, err := ioutil.ReadAll(r)
+		should.NoError(err)
+		read <- b
+	}()
+
+	for i := 0; i < 2; i++ {
+		pkg := nextPayload(t, c)
+		should.Equal([]interface{}{streamReadEvent, uint64(7), 1}, pkg.Data)
+	}
+	should.Equal("hello world", string(<-read))
+
+	args = []reflect.Value{reflect.ValueOf(streamRef{Stream: true, ID: 8})}
+	c.streams.acceptStreams(nc, []reflect.Type{readerType}, args)
+
+	go func() {
+		_ = args[0].Interface().(io.Closer).Close()
+	}()
+
+	pkg := nextPayload(t, c)
+	should.Equal([]interface{}{streamCancelEvent, uint64(8)}, pkg.Data)
+
+	args = []reflect.Value{reflect.ValueOf(streamRef{})}
+	c.streams.acceptStreams(nc, []reflect.Type{readerType}, args)
+	should.Nil(args[0].Interface())
+}
+
+func TestStreamOptIn(t *testing.T) {
+	should := assert.New(t)
+
+	c, nc := newStreamConn()
+	defer close(c.quitChan)
+
+	// a reader not marked by Stream is emitted as a value, and the args of
+	// the caller are kept.
+	r := bytes.NewReader(nil)
+	args := []interface{}{"name", r, Stream(bytes.NewReader(nil))}
+	go nc.Emit("upload", args...)
+
+	pkg := nextPayload(t, c)
+	should.Equal([]interface{}{"upload", "name", r, streamRef{Stream: true, ID: 1}}, pkg.Data)
+	should.Same(r, args[1])
+	should.IsType(streamArg{}, args[2])
+}
+
+func TestStreamBeyondWindow(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	c, nc := newStreamConn()
+	defer close(c.quitChan)
+
+	args := []reflect.Value{reflect.ValueOf(streamRef{Stream: true, ID: 7})}
+	readers := c.streams.acceptStreams(nc, []reflect.Type{readerType}, args)
+	must.Len(readers, 1)
+
+	id := reflect.ValueOf(uint64(7))
+	go func() {
+		for i := 0; i <= streamWindow; i++ {
+			c.streams.handle(nc, streamChunkEvent, []reflect.Value{id, reflect.ValueOf(parser.Buffer{Data: []byte{1}})})
+		}
+	}()
+
+	// the stream is canceled instead of missing the chunk.
+	pkg := nextPayload(t, c)
+	should.Equal([]interface{}{streamCancelEvent, uint64(7)}, pkg.Data)
+
+	go func() {
+		for {
+			select {
+			case <-c.writeChan:
+			case <-c.quitChan:
+				return
+			}
+		}
+	}()
+
+	b, err := ioutil.ReadAll(readers[0])
+	should.Equal(errStreamWindow, err)
+	should.Len(b, streamWindow)
+
+	c.streams.mu.Lock()
+	should.Empty(c.streams.in)
+	c.streams.mu.Unlock()
+}
+
+func TestStreamClosedAfterHandler(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	c, nc := newStreamConn()
+	defer close(c.quitChan)
+	c.namespaces.Set("/files", nc)
+
+	handled := make(chan struct{})
+	handler := newNamespaceHandler("/files", newTracer(), nil)
+	handler.OnEvent("upload", func(Conn, io.Reader) {
+		close(handled)
+	})
+	c.handlers.Set("/files", handler)
+
+	c.decoder = parser.NewDecoder(&fakeReader{data: [][]byte{
+		[]byte(`2/files,["upload",{"_stream":true,"id":9}]`),
+	}})
+
+	header := parser.Header{}
+	event := ""
+	must.NoError(c.decoder.DecodeHeader(&header, &event))
+	must.NoError(eventPacketHandler(c, event, header))
+
+	<-handled
+
+	// the stream ignored by the handler is canceled once it returns.
+	pkg := nextPayload(t, c)
+	should.Equal([]interface{}{streamCancelEvent, uint64(9)}, pkg.Data)
+
+	c.streams.mu.Lock()
+	should.Empty(c.streams.in)
+	c.streams.mu.Unlock()
+}
@@ -0,0 +1,137 @@
+package socketio
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/googollee/go-socket.io"
+
+// span names
+const (
+	eventSpanName     = "socket.io event"
+	emitSpanName      = "socket.io emit"
+	ackSpanName       = "socket.io ack"
+	emitAckSpanName   = "socket.io emit ack"
+	broadcastSpanName = "socket.io broadcast"
+	publishSpanName   = "socket.io adapter publish"
+	receiveSpanName   = "socket.io adapter receive"
+)
+
+// span attributes
+const (
+	namespaceAttr = attribute.Key("socketio.namespace")
+	eventAttr     = attribute.Key("socketio.event")
+	roomAttr      = attribute.Key("socketio.room")
+	sidAttr       = attribute.Key("socketio.sid")
+)
+
+// tracer traces a server or client. It is shared by all connections and
+// adapters, and does nothing until configured.
+type tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	payload    bool
+}
+
+func newTracer() *tracer {
+	return &tracer{
+		tracer:     noop.NewTracerProvider().Tracer(tracerName),
+		propagator: propagation.TraceContext{},
+	}
+}
+
+// configure applies opts. It must be called before serving connections.
+func (t *tracer) configure(opts *TracingOptions) {
+	t.tracer = opts.getTracerProvider().Tracer(tracerName)
+	t.propagator = opts.getPropagator()
+	t.payload = opts.getPayloadPropagation()
+}
+
+func (t *tracer) start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// connContext gives the trace context a peer sent in the handshake of conn,
+// either in the headers of the engine.io handshake or in the auth payload of
+// the CONNECT packet.
+func (t *tracer) connContext(conn Conn) context.Context {
+	h := conn.Handshake()
+
+	ctx := t.propagator.Extract(context.Background(), propagation.HeaderCarrier(h.Headers))
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	return t.propagator.Extract(ctx, authCarrier(h.Auth))
+}
+
+// eventContext gives the trace context a peer sent with an event, whose args
+// beyond the handler are rest. ok is false if the event has no trace context,
+// then rest is kept untouched.
+func (t *tracer) eventContext(rest []interface{}) (context.Context, bool) {
+	if !t.payload || len(rest) == 0 {
+		return nil, false
+	}
+
+	carrier, ok := rest[len(rest)-1].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	ctx := t.extract(carrier)
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil, false
+	}
+
+	return ctx, true
+}
+
+// extract gives the trace context in carrier, a decoded JSON object.
+func (t *tracer) extract(carrier map[string]interface{}) context.Context {
+	return t.propagator.Extract(context.Background(), authCarrier(carrier))
+}
+
+// inject gives the trace context of ctx as an event argument.
+func (t *tracer) inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	t.propagator.Inject(ctx, carrier)
+
+	return carrier
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// authCarrier is a propagation.TextMapCarrier reading the string values of a
+// JSON object, like the auth payload or the trace context argument.
+type authCarrier map[string]interface{}
+
+func (c authCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+func (c authCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+func (c authCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
@@ -0,0 +1,38 @@
+package socketio
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingOptions is configuration to trace events, acks and broadcasts.
+type TracingOptions struct {
+	// TracerProvider creates the tracer. The global provider is used by default.
+	TracerProvider trace.TracerProvider
+	// Propagator carries trace context between peers and nodes. W3C trace
+	// context is used by default.
+	Propagator propagation.TextMapPropagator
+	// PayloadPropagation carries trace context in events as an extra last
+	// argument, an object like {"traceparent": "..."}. Without it trace
+	// context is only read from the handshake headers or auth payload.
+	PayloadPropagation bool
+}
+
+func (o *TracingOptions) getTracerProvider() trace.TracerProvider {
+	if o != nil && o.TracerProvider != nil {
+		return o.TracerProvider
+	}
+	return otel.GetTracerProvider()
+}
+
+func (o *TracingOptions) getPropagator() propagation.TextMapPropagator {
+	if o != nil && o.Propagator != nil {
+		return o.Propagator
+	}
+	return propagation.TraceContext{}
+}
+
+func (o *TracingOptions) getPayloadPropagation() bool {
+	return o != nil && o.PayloadPropagation
+}
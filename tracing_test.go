@@ -0,0 +1,142 @@
+package socketio
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/googollee/go-socket.io/metrics"
+	"github.com/googollee/go-socket.io/parser"
+)
+
+func TestTracingEvent(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	namespace := "/test"
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	c := &conn{
+		Conn:       &fakeEngineConn{id: "sid"},
+		tracer:     newTracer(),
+		metrics:    metrics.Nop{},
+		handlers:   newNamespaceHandlers(),
+		namespaces: newNamespaces(),
+		writeChan:  make(chan parser.Payload, 1),
+		quitChan:   make(chan struct{}),
+		decoder: parser.NewDecoder(&fakeReader{data: [][]byte{
+			[]byte(`2/test,["msg","hello",{"traceparent":"` + traceparent + `"}]`),
+		}}),
+	}
+	c.tracer.configure(&TracingOptions{TracerProvider: provider, PayloadPropagation: true})
+
+	conn := newNamespaceConn(c, namespace, nil)
+	c.namespaces.Set(namespace, conn)
+
+	var got string
+	var handled trace.SpanContext
+	handler := newNamespaceHandler(namespace, newTracer(), nil)
+	handler.OnEvent("msg", func(conn Conn, msg string) {
+		got = msg
+		handled = trace.SpanContextFromContext(TraceContext(conn))
+		conn.Emit("reply", msg)
+	})
+	c.handlers.Set(namespace, handler)
+
+	header := parser.Header{}
+	event := ""
+	must.NoError(c.decoder.DecodeHeader(&header, &event))
+	must.NoError(eventPacketHandler(c, event, header))
+
+	should.Equal("hello", got)
+	should.False(trace.SpanContextFromContext(TraceContext(conn)).IsValid())
+
+	spans := recorder.Ended()
+	must.Len(spans, 2)
+
+	emit, span := spans[0], spans[1]
+	should.Equal(emitSpanName, emit.Name())
+	should.Equal(eventSpanName, span.Name())
+	should.Equal("4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	should.Equal("00f067aa0ba902b7", span.Parent().SpanID().String())
+	should.True(span.Parent().IsRemote())
+	should.Equal(span.SpanContext(), handled)
+	should.Equal(span.SpanContext().SpanID(), emit.Parent().SpanID())
+	should.Contains(span.Attributes(), eventAttr.String("msg"))
+	should.Contains(span.Attributes(), namespaceAttr.String(namespace))
+
+	pkg := <-c.writeChan
+	must.Len(pkg.Data, 3)
+	should.Equal("hello", pkg.Data[1])
+	carrier, ok := pkg.Data[2].(map[string]string)
+	must.True(ok)
+	should.Contains(carrier["traceparent"], emit.SpanContext().SpanID().String())
+}
+
+func TestTracingBroadcast(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	tr := newTracer()
+	tr.configure(&TracingOptions{TracerProvider: provider})
+
+	ctx, parent := tr.start(context.Background(), eventSpanName, trace.SpanKindConsumer)
+	bc := newBroadcast("/test", tr)
+	bc.Send(ctx, "room", "msg", "hello")
+	bc.SendAll(ctx, "msg", "hello")
+	parent.End()
+
+	spans := recorder.Ended()
+	must.Len(spans, 3)
+	for _, span := range spans[:2] {
+		should.Equal(broadcastSpanName, span.Name())
+		should.Equal(parent.SpanContext().SpanID(), span.Parent().SpanID())
+		should.Contains(span.Attributes(), namespaceAttr.String("/test"))
+	}
+	should.Contains(spans[0].Attributes(), roomAttr.String("room"))
+}
+
+func TestTracingAdapterReceive(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	tr := newTracer()
+	tr.configure(&TracingOptions{TracerProvider: provider})
+
+	bc := &redisBroadcast{
+		metrics: metrics.Nop{},
+		tracer:  tr,
+		rooms:   make(map[string]map[string]Conn),
+		nsp:     "/test",
+		uid:     "local",
+	}
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	msg := `{"opts":["room","msg"],"args":["hello"],"trace":[{"traceparent":"` + traceparent + `"}]}`
+	must.NoError(bc.onMessage("socket.io#/test#remote", []byte(msg)))
+
+	// the fan-out to the local connections is a child of the receive span,
+	// which is a child of the publish span of the other node.
+	spans := recorder.Ended()
+	must.Len(spans, 2)
+
+	send, receive := spans[0], spans[1]
+	should.Equal(broadcastSpanName, send.Name())
+	should.Equal(receiveSpanName, receive.Name())
+	should.Equal(receive.SpanContext().SpanID(), send.Parent().SpanID())
+	should.Equal("4bf92f3577b34da6a3ce929d0e0e4736", send.SpanContext().TraceID().String())
+	should.Equal("00f067aa0ba902b7", receive.Parent().SpanID().String())
+}
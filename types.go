@@ -13,6 +13,7 @@ const (
 // message
 const (
 	clientDisconnectMsg = "client namespace disconnect"
+	pingTimeoutMsg      = "ping timeout"
 )
 
 var (
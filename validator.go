@@ -0,0 +1,76 @@
+package socketio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// validationErrorEvent is the event emitted to a client whose event without
+// ack is rejected by a Validator.
+const validationErrorEvent = "validation_error"
+
+// Validator validates the args of an event before they are decoded for the
+// handler. args are the raw JSON of all the args sent by the client.
+type Validator interface {
+	Validate(args []json.RawMessage) error
+}
+
+// ValidatorFunc is an adapter to use a function as a Validator.
+type ValidatorFunc func(args []json.RawMessage) error
+
+// Validate calls f(args).
+func (f ValidatorFunc) Validate(args []json.RawMessage) error {
+	return f(args)
+}
+
+// ValidationError is sent to a client whose event is rejected by a Validator,
+// as the ack of the event or else with the validation_error event.
+type ValidationError struct {
+	Event   string `json:"event"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid args of event %s: %s", e.Event, e.Message)
+}
+
+type schemaValidator struct {
+	schema *jsonschema.Schema
+}
+
+// NewSchemaValidator returns a Validator checking the payload of events, the
+// first arg, against the JSON Schema in schema. A missing payload is validated
+// as null.
+func NewSchemaValidator(schema []byte) (Validator, error) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("schema.json", bytes.NewReader(schema)); err != nil {
+		return nil, err
+	}
+
+	compiled, err := compiler.Compile("schema.json")
+	if err != nil {
+		return nil, err
+	}
+
+	return &schemaValidator{schema: compiled}, nil
+}
+
+func (v *schemaValidator) Validate(args []json.RawMessage) error {
+	payload := json.RawMessage("null")
+	if len(args) > 0 {
+		payload = args[0]
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	return v.schema.Validate(value)
+}
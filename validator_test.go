@@ -0,0 +1,95 @@
+package socketio
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/googollee/go-socket.io/logger"
+	"github.com/googollee/go-socket.io/metrics"
+	"github.com/googollee/go-socket.io/parser"
+)
+
+func TestSchemaValidator(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	v, err := NewSchemaValidator([]byte(`{
+		"type": "object",
+		"properties": {"name": {"type": "string"}},
+		"required": ["name"]
+	}`))
+	must.NoError(err)
+
+	should.NoError(v.Validate([]json.RawMessage{json.RawMessage(`{"name":"a"}`)}))
+	should.Error(v.Validate([]json.RawMessage{json.RawMessage(`{"age":1}`)}))
+	should.Error(v.Validate(nil))
+
+	_, err = NewSchemaValidator([]byte(`{"type": 1}`))
+	should.Error(err)
+}
+
+func TestEventValidation(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	namespace := "/test"
+	c := &conn{
+		Conn:       &fakeEngineConn{id: "sid"},
+		tracer:     newTracer(),
+		metrics:    metrics.Nop{},
+		handlers:   newNamespaceHandlers(),
+		namespaces: newNamespaces(),
+		writeChan:  make(chan parser.Payload, 1),
+		quitChan:   make(chan struct{}),
+	}
+	c.logger = logger.Log
+
+	conn := newNamespaceConn(c, namespace, nil)
+	c.namespaces.Set(namespace, conn)
+
+	var called []string
+	handler := newNamespaceHandler(namespace, newTracer(), nil)
+	handler.OnEvent("msg", func(conn Conn, msg map[string]string) {
+		called = append(called, msg["name"])
+	})
+	handler.OnEventValidator("msg", ValidatorFunc(func(args []json.RawMessage) error {
+		if len(args) == 0 || string(args[0]) == "{}" {
+			return errors.New("missing name")
+		}
+		return nil
+	}))
+	c.handlers.Set(namespace, handler)
+
+	handle := func(packet string) {
+		c.decoder = parser.NewDecoder(&fakeReader{data: [][]byte{[]byte(packet)}})
+
+		header := parser.Header{}
+		event := ""
+		must.NoError(c.decoder.DecodeHeader(&header, &event))
+		must.NoError(eventPacketHandler(c, event, header))
+	}
+
+	handle(`2/test,["msg",{"name":"a"}]`)
+	should.Equal([]string{"a"}, called)
+
+	handle(`2/test,["msg",{}]`)
+	should.Equal([]string{"a"}, called)
+
+	pkg := <-c.writeChan
+	should.Equal(parser.Event, pkg.Header.Type)
+	must.Len(pkg.Data, 2)
+	should.Equal(validationErrorEvent, pkg.Data[0])
+	should.Equal(&ValidationError{Event: "msg", Message: "missing name"}, pkg.Data[1])
+
+	handle(`2/test,1["msg"]`)
+	should.Equal([]string{"a"}, called)
+
+	pkg = <-c.writeChan
+	should.Equal(parser.Ack, pkg.Header.Type)
+	should.Equal(uint64(1), pkg.Header.ID)
+	should.Equal([]interface{}{&ValidationError{Event: "msg", Message: "missing name"}}, pkg.Data)
+}